@@ -0,0 +1,243 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"container/list"
+)
+
+// assert match interface
+var _ Cache = (*ARCCache)(nil)
+
+// ARCCache is an Adaptive Replacement Cache implementation.
+//
+// The entries seen once recently are kept in the T1 list, and the
+// entries seen at least twice are kept in the T2 list.  The keys of
+// the entries evicted from T1 and T2 are remembered in the ghost lists
+// B1 and B2, a miss hits a ghost list moves the target size of T1
+// toward recency (B1) or frequency (B2), so the cache self-tunes for
+// the workload.
+//
+// All the lists are measured by the Size() of the entries, not the
+// number of the entries.  The ghost entries hold no values.
+//
+// See https://www.usenix.org/legacy/events/fast03/tech/full_papers/megiddo/megiddo.pdf
+type ARCCache struct {
+	*policyCache
+}
+
+// NewARCCache creates a new empty ARC cache with the given capacity.
+// The WithTTL and WithIdleTimeout options are not supported.
+func NewARCCache(capacity int64, opts ...Option) *ARCCache {
+	policy := newARCPolicy()
+	p := newPolicyCache(capacity, policy, opts...)
+	policy.capacity = &p.capacity
+	return &ARCCache{p}
+}
+
+// Target returns the target size of the T1 list, it is between 0 and
+// the capacity.
+func (p *ARCCache) Target() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.policy.(*arcPolicy).target
+}
+
+type arcPolicy struct {
+	capacity *int64
+
+	// resident entries, the front is the most recently used
+	t1, t2         *list.List // of *LRUHandle
+	t1size, t2size int64
+
+	// ghost entries
+	b1, b2 *ghostQueue
+
+	// target size of t1
+	target int64
+
+	// the new entry hits the ghost list b1 or b2, set by prepare
+	pending *ghostQueue
+}
+
+// arcLink is the LRUHandle.link of arcPolicy.
+type arcLink struct {
+	list    *list.List
+	element *list.Element
+	size    int64
+}
+
+func newARCPolicy() *arcPolicy {
+	return &arcPolicy{
+		t1: list.New(),
+		t2: list.New(),
+		b1: newGhostQueue(),
+		b2: newGhostQueue(),
+	}
+}
+
+// prepare adapts the target size if the key is a ghost.
+func (p *arcPolicy) prepare(key string, size int64) {
+	p.pending = nil
+
+	if size, ok := p.b1.lookup(key); ok {
+		delta := size
+		if p.b1.size > 0 && p.b2.size > p.b1.size {
+			delta = size * p.b2.size / p.b1.size
+		}
+		if p.target += delta; p.target > *p.capacity {
+			p.target = *p.capacity
+		}
+		p.pending = p.b1
+	} else if size, ok := p.b2.lookup(key); ok {
+		delta := size
+		if p.b2.size > 0 && p.b1.size > p.b2.size {
+			delta = size * p.b1.size / p.b2.size
+		}
+		if p.target -= delta; p.target < 0 {
+			p.target = 0
+		}
+		p.pending = p.b2
+	} else {
+		return
+	}
+	p.pending.remove(key)
+}
+
+// add puts a new entry to the front of t1, or t2 if the key is a
+// ghost.
+func (p *arcPolicy) add(h *LRUHandle) {
+	if p.pending != nil {
+		p.push(p.t2, h, h.size)
+	} else {
+		p.push(p.t1, h, h.size)
+	}
+	p.pending = nil
+	p.trimGhosts()
+}
+
+// hit moves the entry to the front of t2.
+func (p *arcPolicy) hit(h *LRUHandle) {
+	link := h.link.(*arcLink)
+	p.unlink(link)
+	p.push(p.t2, h, link.size)
+}
+
+func (p *arcPolicy) remove(h *LRUHandle) {
+	p.unlink(h.link.(*arcLink))
+	h.link = nil
+}
+
+// evict removes the entry, and remembers its key in b1 or b2.
+func (p *arcPolicy) evict(h *LRUHandle) {
+	link := h.link.(*arcLink)
+	p.remove(h)
+
+	if link.list == p.t1 {
+		p.b1.push(h, link.size)
+	} else {
+		p.b2.push(h, link.size)
+	}
+	p.trimGhosts()
+}
+
+// replace puts h to the place of old in t1 or t2.
+func (p *arcPolicy) replace(old, h *LRUHandle) {
+	link := old.link.(*arcLink)
+	link.element.Value = h
+	h.link, old.link = link, nil
+	p.resized(h)
+}
+
+func (p *arcPolicy) resized(h *LRUHandle) {
+	link := h.link.(*arcLink)
+	if link.list == p.t1 {
+		p.t1size += h.size - link.size
+	} else {
+		p.t2size += h.size - link.size
+	}
+	link.size = h.size
+}
+
+func (p *arcPolicy) front() *LRUHandle {
+	if e := p.t2.Front(); e != nil {
+		return e.Value.(*LRUHandle)
+	}
+	if e := p.t1.Front(); e != nil {
+		return e.Value.(*LRUHandle)
+	}
+	return nil
+}
+
+// oldest returns the back of t1 if t1 exceeds the target size, else the
+// back of t2.
+func (p *arcPolicy) oldest() *LRUHandle {
+	if p.t1.Len() > 0 {
+		if p.t2.Len() == 0 || p.t1size > p.target || (p.pending == p.b2 && p.t1size == p.target) {
+			return p.t1.Back().Value.(*LRUHandle)
+		}
+	}
+	if e := p.t2.Back(); e != nil {
+		return e.Value.(*LRUHandle)
+	}
+	return nil
+}
+
+// appendKeys appends the keys of t2 and then t1, both from the most
+// recently used to the least recently used.
+func (p *arcPolicy) appendKeys(keys []string) []string {
+	for _, l := range []*list.List{p.t2, p.t1} {
+		for e := l.Front(); e != nil; e = e.Next() {
+			keys = append(keys, e.Value.(*LRUHandle).key)
+		}
+	}
+	return keys
+}
+
+func (p *arcPolicy) clear() {
+	p.t1.Init()
+	p.t2.Init()
+	p.b1.clear()
+	p.b2.clear()
+	p.t1size, p.t2size = 0, 0
+	p.target = 0
+	p.pending = nil
+}
+
+func (p *arcPolicy) push(l *list.List, h *LRUHandle, size int64) {
+	if l == p.t1 {
+		p.t1size += size
+	} else {
+		p.t2size += size
+	}
+	h.link = &arcLink{list: l, element: l.PushFront(h), size: size}
+}
+
+func (p *arcPolicy) unlink(link *arcLink) {
+	if link.list == p.t1 {
+		p.t1size -= link.size
+	} else {
+		p.t2size -= link.size
+	}
+	link.list.Remove(link.element)
+}
+
+// trimGhosts keeps t1+b1 within the capacity, and all the lists
+// within twice the capacity.
+func (p *arcPolicy) trimGhosts() {
+	capacity := *p.capacity
+	for p.b1.Len() > 0 && p.t1size+p.b1.size > capacity {
+		p.b1.removeBack()
+	}
+	for p.t1size+p.t2size+p.b1.size+p.b2.size > 2*capacity {
+		if p.b2.Len() > 0 {
+			p.b2.removeBack()
+		} else if p.b1.Len() > 0 {
+			p.b1.removeBack()
+		} else {
+			break
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"testing"
+)
+
+func TestARCCache(t *testing.T) {
+	var deleted []string
+	c := NewARCCache(4, WithDeleter(func(key string, value interface{}) {
+		deleted = append(deleted, key)
+	}))
+	defer c.Close()
+
+	c.Set("a", 1, 1)
+	c.Set("b", 2, 1)
+	c.Set("c", 3, 1)
+	c.Set("d", 4, 1)
+	c.Get("a")
+	c.Get("b")
+	tAssertEQ(t, []string{"b", "a", "d", "c"}, c.Keys())
+
+	// t1 exceeds the target
+	c.Set("e", 5, 1)
+	tAssertEQ(t, []string{"c"}, deleted)
+	tAssertEQ(t, []string{"b", "a", "e", "d"}, c.Keys())
+	tAssertEQ(t, int64(0), c.Target())
+
+	// ghost hit of b1 grows the target, and goes to t2
+	c.Set("c", 3, 1)
+	tAssertEQ(t, int64(1), c.Target())
+	tAssertEQ(t, []string{"c", "d"}, deleted)
+	tAssertEQ(t, []string{"c", "b", "a", "e"}, c.Keys())
+
+	// t1 does not exceed the target, evicts t2
+	c.Set("f", 6, 1)
+	tAssertEQ(t, []string{"c", "d", "a"}, deleted)
+	tAssertEQ(t, []string{"c", "b", "f", "e"}, c.Keys())
+
+	// ghost hit of b2 shrinks the target
+	c.Set("a", 1, 1)
+	tAssertEQ(t, int64(0), c.Target())
+	tAssertEQ(t, []string{"c", "d", "a", "e"}, deleted)
+	tAssertEQ(t, []string{"a", "c", "b", "f"}, c.Keys())
+	tAssertEQ(t, "a", c.FrontKey())
+	tAssertEQ(t, "f", c.BackKey())
+
+	// the ghosts are cleared too
+	c.Clear()
+	arc := c.policy.(*arcPolicy)
+	tAssertEQ(t, 0, arc.b1.Len()+arc.b2.Len())
+}
+
+func TestARCCache_weighted(t *testing.T) {
+	c := NewARCCache(10)
+	defer c.Close()
+
+	c.Set("a", 1, 6)
+	c.Set("b", 2, 4)
+	c.Get("a")
+	c.Set("c", 3, 3)
+	tAssertEQ(t, []string{"a", "c"}, c.Keys())
+	tAssertEQ(t, int64(9), c.Size())
+
+	// the ghost of b holds its size
+	arc := c.policy.(*arcPolicy)
+	tAssertEQ(t, int64(4), arc.b1.size)
+	tAssertEQ(t, int64(3), arc.t1size)
+	tAssertEQ(t, int64(6), arc.t2size)
+
+	tAssertNil(t, c.Resize("c", 4))
+	tAssertEQ(t, int64(4), arc.t1size)
+	tAssertEQ(t, int64(10), c.Size())
+
+	// t1 does not exceed the new target, evicts a from t2
+	c.Set("b", 2, 4)
+	tAssertEQ(t, int64(4), c.Target())
+	tAssertEQ(t, []string{"b", "c"}, c.Keys())
+	tAssertEQ(t, int64(4), arc.t1size)
+	tAssertEQ(t, int64(4), arc.t2size)
+	tAssertEQ(t, int64(6), arc.b2.size)
+}
@@ -51,3 +51,31 @@ type Cache interface {
 func New(capacity int64) Cache {
 	return NewLRUCache(capacity)
 }
+
+// TypedCache is the type-safe version of Cache.
+type TypedCache[K comparable, V any] interface {
+	// Return a new numeric id.
+	NewId() uint64
+
+	// Insert a mapping from key->value into the cache and assign it
+	// the specified size against the total cache capacity.
+	//
+	// Return a handle that corresponds to the mapping.  The caller
+	// must call handle.Close() when the returned mapping is no
+	// longer needed.
+	Insert(key K, value V, size int, deleter func(key K, value V)) (handle *Handle[K, V])
+
+	// If the cache has no mapping for "key", returns zero value, nil, false.
+	//
+	// Else return a handle that corresponds to the mapping.  The caller
+	// must call handle.Close() when the returned mapping is no
+	// longer needed.
+	Lookup(key K) (value V, handle *Handle[K, V], ok bool)
+
+	// If the cache contains entry for key, erase it.
+	Erase(key K)
+
+	// Destroys all existing entries by calling the "deleter".
+	// REQUIRES: all handles must have been released.
+	Close() error
+}
@@ -21,9 +21,9 @@ type TCache struct {
 	deleted_values_ []int
 }
 
-func tNewTCache(capacity int64) *TCache {
+func tNewTCache(capacity int64, opts ...Option) *TCache {
 	return &TCache{
-		LRUCache: NewLRUCache(capacity),
+		LRUCache: NewLRUCache(capacity, opts...),
 	}
 }
 
@@ -0,0 +1,41 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(t0)
+	tAssertEQ(t, t0, clock.Now())
+
+	clock.Advance(time.Second)
+	tAssertEQ(t, t0.Add(time.Second), clock.Now())
+
+	clock.Set(t0)
+	tAssertEQ(t, t0, clock.Now())
+}
+
+func TestLRUCache_clock(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(t0)
+	c := NewLRUCache(tCacheSize, WithClock(clock))
+	defer c.Close()
+
+	c.Set("a", 1, 1)
+	clock.Advance(time.Second)
+	c.Set("b", 2, 1)
+
+	tAssertEQ(t, t0, c.Oldest())
+	tAssertEQ(t, t0.Add(time.Second), c.Newest())
+
+	clock.Advance(time.Second)
+	c.Get("a")
+	tAssertEQ(t, t0.Add(time.Second), c.Oldest())
+	tAssertEQ(t, t0.Add(2*time.Second), c.Newest())
+}
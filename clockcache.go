@@ -0,0 +1,166 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"container/list"
+	"sync/atomic"
+)
+
+// assert match interface
+var _ Cache = (*ClockCache)(nil)
+
+// ClockCache is a CLOCK (second chance) cache implementation.
+//
+// The entries are kept in a ring with a clock hand, a hit only sets
+// the reference bit of the entry atomically, so the concurrent hits
+// share the read lock and never mutate the ring.  To choose a victim,
+// the hand sweeps the ring, clears the set reference bits, and stops
+// at the first entry whose bit is not set.  The new entries are
+// inserted just behind the hand, with the bit not set.
+//
+// Note the capacity is not the number of items, but the total sum of
+// the Size() of each item.
+type ClockCache struct {
+	*policyCache
+}
+
+// NewClockCache creates a new empty CLOCK cache with the given capacity.
+// The WithTTL and WithIdleTimeout options are not supported.
+func NewClockCache(capacity int64, opts ...Option) *ClockCache {
+	return &ClockCache{newPolicyCache(capacity, &clockPolicy{ring: list.New()}, opts...)}
+}
+
+type clockPolicy struct {
+	ring *list.List    // of *LRUHandle
+	hand *list.Element // nil if the ring is empty
+}
+
+// clockLink is the LRUHandle.link of clockPolicy.
+type clockLink struct {
+	element    *list.Element
+	referenced uint32 // set by hits atomically
+}
+
+// next returns the next element of e in the ring.
+func (p *clockPolicy) next(e *list.Element) *list.Element {
+	if next := e.Next(); next != nil {
+		return next
+	}
+	return p.ring.Front()
+}
+
+// prev returns the previous element of e in the ring.
+func (p *clockPolicy) prev(e *list.Element) *list.Element {
+	if prev := e.Prev(); prev != nil {
+		return prev
+	}
+	return p.ring.Back()
+}
+
+// add inserts the entry just behind the hand, so it is the last one
+// swept by the hand.
+func (p *clockPolicy) add(h *LRUHandle) {
+	var e *list.Element
+	if p.hand == nil {
+		e = p.ring.PushBack(h)
+		p.hand = e
+	} else {
+		e = p.ring.InsertBefore(h, p.hand)
+	}
+	h.link = &clockLink{element: e}
+}
+
+func (p *clockPolicy) hit(h *LRUHandle) {
+	p.sharedHit(h)
+}
+
+// sharedHit sets the reference bit, it is safe with the read lock.
+func (p *clockPolicy) sharedHit(h *LRUHandle) {
+	link := h.link.(*clockLink)
+	if atomic.LoadUint32(&link.referenced) == 0 {
+		atomic.StoreUint32(&link.referenced, 1)
+	}
+}
+
+func (p *clockPolicy) remove(h *LRUHandle) {
+	e := h.link.(*clockLink).element
+	if p.hand == e {
+		if p.hand = p.next(e); p.hand == e {
+			p.hand = nil
+		}
+	}
+	p.ring.Remove(e)
+	h.link = nil
+}
+
+// replace puts h to the place of old, the reference bit is kept.
+func (p *clockPolicy) replace(old, h *LRUHandle) {
+	link := old.link.(*clockLink)
+	link.element.Value = h
+	h.link, old.link = link, nil
+}
+
+// front returns the entry just behind the hand.
+func (p *clockPolicy) front() *LRUHandle {
+	if p.hand == nil {
+		return nil
+	}
+	return p.prev(p.hand).Value.(*LRUHandle)
+}
+
+// oldest returns the first entry from the hand whose reference bit is
+// not set, or the entry at the hand if all the bits are set.  Unlike
+// victim, the hand and the bits are not changed.
+func (p *clockPolicy) oldest() *LRUHandle {
+	if p.hand == nil {
+		return nil
+	}
+	for e := p.hand; ; {
+		h := e.Value.(*LRUHandle)
+		if atomic.LoadUint32(&h.link.(*clockLink).referenced) == 0 {
+			return h
+		}
+		if e = p.next(e); e == p.hand {
+			return p.hand.Value.(*LRUHandle)
+		}
+	}
+}
+
+// victim sweeps the hand to the first entry whose reference bit is not
+// set, the set bits are cleared on the way.
+func (p *clockPolicy) victim() *LRUHandle {
+	if p.hand == nil {
+		return nil
+	}
+	for {
+		h := p.hand.Value.(*LRUHandle)
+		link := h.link.(*clockLink)
+		if atomic.LoadUint32(&link.referenced) == 0 {
+			return h
+		}
+		atomic.StoreUint32(&link.referenced, 0)
+		p.hand = p.next(p.hand)
+	}
+}
+
+// appendKeys appends the keys from the entry just behind the hand
+// backward to the hand, the reference bits are ignored.
+func (p *clockPolicy) appendKeys(keys []string) []string {
+	if p.hand == nil {
+		return keys
+	}
+	for e := p.prev(p.hand); ; e = p.prev(e) {
+		keys = append(keys, e.Value.(*LRUHandle).key)
+		if e == p.hand {
+			return keys
+		}
+	}
+}
+
+func (p *clockPolicy) clear() {
+	p.ring.Init()
+	p.hand = nil
+}
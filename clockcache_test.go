@@ -0,0 +1,84 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestClockCache(t *testing.T) {
+	var deleted []string
+	c := NewClockCache(3, WithDeleter(func(key string, value interface{}) {
+		deleted = append(deleted, key)
+	}))
+	defer c.Close()
+
+	c.Set("a", 1, 1)
+	c.Set("b", 2, 1)
+	c.Set("c", 3, 1)
+	tAssertEQ(t, []string{"c", "b", "a"}, c.Keys())
+
+	// the referenced entry has a second chance
+	c.Get("a")
+	c.Set("d", 4, 1)
+	tAssertEQ(t, []string{"b"}, deleted)
+	tAssertEQ(t, []string{"d", "a", "c"}, c.Keys())
+
+	c.Set("e", 5, 1)
+	tAssertEQ(t, []string{"b", "c"}, deleted)
+	tAssertEQ(t, []string{"e", "d", "a"}, c.Keys())
+	tAssertEQ(t, "e", c.FrontKey())
+	tAssertEQ(t, "a", c.BackKey())
+
+	// all referenced, the hand sweeps a full round
+	c.Get("a")
+	c.Get("d")
+	c.Get("e")
+	c.Set("f", 6, 1)
+	tAssertEQ(t, []string{"b", "c", "a"}, deleted)
+
+	c.Erase("d")
+	c.Erase("e")
+	c.Erase("f")
+	tAssertEQ(t, 0, len(c.Keys()))
+	tAssertTrue(t, c.BackKey() == "")
+
+	c.Set("g", 7, 1)
+	tAssertEQ(t, []string{"g"}, c.Keys())
+}
+
+func TestClockCache_concurrentHits(t *testing.T) {
+	c := NewClockCache(100)
+	defer c.Close()
+
+	for i := 0; i < 100; i++ {
+		c.Set(strconv.Itoa(i), i, 1)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := strconv.Itoa((g*7 + i) % 200)
+				if v, h, ok := c.Lookup_(key); ok {
+					tAssertEQ(t, key, strconv.Itoa(v.(int)))
+					h.Close()
+				} else if g%2 == 0 {
+					n, _ := strconv.Atoi(key)
+					c.Set(key, n, 1)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	tAssertEQ(t, int64(100), c.Length())
+	stats := c.CacheStats()
+	tAssertEQ(t, uint64(8000), stats.Hits+stats.Misses)
+}
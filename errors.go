@@ -0,0 +1,28 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidKey         = errors.New("cache: invalid key!")
+	ErrInvalidSize        = errors.New("cache: invalid size!")
+	ErrInvalidCapacity    = errors.New("cache: invalid capacity!")
+	ErrHandlesOutstanding = errors.New("cache: handles outstanding!")
+	ErrNotFound           = errors.New("cache: not found!")
+)
+
+// checkEntry checks the key and size of a new entry.
+func checkEntry(key string, size int) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	if size <= 0 {
+		return ErrInvalidSize
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"testing"
+)
+
+func TestLRUCache_tryErrors(t *testing.T) {
+	_, err := TryNewLRUCache(0)
+	tAssertTrue(t, err == ErrInvalidCapacity)
+
+	c, err := TryNewLRUCache(10)
+	tAssertNil(t, err)
+	defer c.Close()
+
+	_, err = c.TryInsert("", 1, 1, nil)
+	tAssertTrue(t, err == ErrInvalidKey)
+	_, err = c.TryInsert("key", 1, 0, nil)
+	tAssertTrue(t, err == ErrInvalidSize)
+	tAssertTrue(t, c.TryPushFront("key", 1, -1, nil) == ErrInvalidSize)
+	tAssertTrue(t, c.TryPushBack("", 1, 1, nil) == ErrInvalidKey)
+	tAssertEQ(t, int64(0), c.Length())
+
+	h, err := c.TryInsert("key", 1, 1, nil)
+	tAssertNil(t, err)
+	h.Close()
+	tAssertNil(t, c.TryPushFront("front", 1, 1, nil))
+	tAssertNil(t, c.TryPushBack("back", 1, 1, nil))
+	tAssertEQ(t, []string{"front", "key", "back"}, c.Keys())
+
+	tAssertTrue(t, c.TrySetCapacity(0) == ErrInvalidCapacity)
+	tAssertEQ(t, int64(10), c.Capacity())
+	tAssertNil(t, c.TrySetCapacity(2))
+	tAssertEQ(t, []string{"front", "key"}, c.Keys())
+}
+
+func TestLRUCache_GetFrom_invalidSize(t *testing.T) {
+	c := NewLRUCache(10)
+	defer c.Close()
+
+	v, err := c.GetFrom("key", func(key string) (interface{}, int, error) {
+		return "value", 0, nil
+	})
+	tAssertTrue(t, err == ErrInvalidSize)
+	tAssertNil(t, v)
+	tAssertFalse(t, c.HasKey("key"))
+}
+
+func TestLRUCache_CloseErr(t *testing.T) {
+	var deleted []string
+	c := NewLRUCache(10)
+
+	h := c.Insert("key", 1, 1, func(key string, value interface{}) {
+		deleted = append(deleted, key)
+	})
+	tAssertTrue(t, c.CloseErr() == ErrHandlesOutstanding)
+	tAssertEQ(t, 1, c.Value("key"))
+	tAssertEQ(t, 0, len(deleted))
+
+	h.Close()
+	tAssertNil(t, c.CloseErr())
+	tAssertEQ(t, []string{"key"}, deleted)
+}
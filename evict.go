@@ -0,0 +1,56 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+// EvictReason is the reason why an entry leaves the cache.
+type EvictReason int
+
+const (
+	EvictCapacity EvictReason = iota + 1 // evicted for the capacity or max entries
+	EvictReplaced                        // replaced by a new entry of the same key
+	EvictErased                          // erased by Erase, Take, PopFront or PopBack
+	EvictCleared                         // cleared by Clear
+	EvictClosed                          // released by Close
+	EvictExpired                         // expired by the ttl or idle timeout
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictReplaced:
+		return "replaced"
+	case EvictErased:
+		return "erased"
+	case EvictCleared:
+		return "cleared"
+	case EvictClosed:
+		return "closed"
+	case EvictExpired:
+		return "expired"
+	}
+	return "unknown"
+}
+
+// OnEvict sets the listener which is called when an entry leaves the
+// cache table, it replaces the listener set by WithEvictionListener.
+//
+// Unlike the deleter which is called when the last handle of the entry
+// released, the listener is called immediately even if the entry is
+// still used by some handles.  The listener is called with the lock
+// held, and must not call the cache.
+func (p *LRUCache) OnEvict(fn func(key string, value interface{}, reason EvictReason)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.on_evict = fn
+}
+
+// evicted notifies the eviction listener.
+// REQUIRES: p.mu must be held.
+func (p *_LRUCache) evicted(h *LRUHandle, reason EvictReason) {
+	if p.on_evict != nil {
+		p.on_evict(h.key, h.value, reason)
+	}
+}
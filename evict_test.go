@@ -0,0 +1,58 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLRUCache_OnEvict(t *testing.T) {
+	var events []string
+	clock := NewFakeClock(time.Now())
+	c := tNewTCache(3, WithClock(clock))
+
+	c.OnEvict(func(key string, value interface{}, reason EvictReason) {
+		events = append(events, fmt.Sprintf("%s:%v:%v", key, value, reason))
+	})
+
+	c.Insert(1, 101)
+	c.Insert(1, 102)
+	c.Insert(2, 201)
+	c.Insert(3, 301)
+	c.Insert(4, 401)
+	c.Erase(2)
+	c.LRUCache.SetWithTTL("5", 501, 1, time.Minute, c.onDeleter)
+	clock.Advance(time.Minute)
+	tAssertEQ(t, -1, c.Lookup(5))
+
+	tAssertEQ(t, []string{
+		"1:101:replaced",
+		"1:102:capacity",
+		"2:201:erased",
+		"5:501:expired",
+	}, events)
+
+	// the listener is called before the handle released
+	events = nil
+	h := c.LRUCache.Insert("6", 601, 1, c.onDeleter)
+	c.Clear()
+	tAssertEQ(t, 3, len(events))
+	deleted := len(c.deleted_keys_)
+	h.Close()
+	tAssertEQ(t, deleted+1, len(c.deleted_keys_))
+
+	events = nil
+	c.Insert(7, 701)
+	c.Close()
+	tAssertEQ(t, []string{"7:701:closed"}, events)
+}
+
+func TestEvictReason_String(t *testing.T) {
+	tAssertEQ(t, "capacity", EvictCapacity.String())
+	tAssertEQ(t, "expired", EvictExpired.String())
+	tAssertEQ(t, "unknown", EvictReason(0).String())
+}
@@ -0,0 +1,116 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"container/list"
+	"sync/atomic"
+)
+
+// fifoQueue is the FIFO queue of the entries shared by SieveCache and
+// S3FIFOCache, the front is the newest.  The hits never move the
+// entries, they only bump the access frequency of the entries with
+// atomic operations, so the hits can share the read lock.
+type fifoQueue struct {
+	list *list.List // of *LRUHandle
+	size int64
+}
+
+// fifoLink is the LRUHandle.link of the entries of fifoQueue.
+type fifoLink struct {
+	queue   *fifoQueue
+	element *list.Element
+	size    int64
+	freq    uint32 // accessed atomically
+}
+
+func newFIFOQueue() *fifoQueue {
+	return &fifoQueue{list: list.New()}
+}
+
+func fifoLinkOf(h *LRUHandle) *fifoLink {
+	return h.link.(*fifoLink)
+}
+
+// fifoFreq returns the access frequency of h.
+func fifoFreq(h *LRUHandle) uint32 {
+	return atomic.LoadUint32(&fifoLinkOf(h).freq)
+}
+
+// fifoSetFreq sets the access frequency of h.
+func fifoSetFreq(h *LRUHandle, freq uint32) {
+	atomic.StoreUint32(&fifoLinkOf(h).freq, freq)
+}
+
+// fifoTouch increases the access frequency of h up to max, it is safe
+// with the read lock.
+func fifoTouch(h *LRUHandle, max uint32) {
+	link := fifoLinkOf(h)
+	for {
+		freq := atomic.LoadUint32(&link.freq)
+		if freq >= max || atomic.CompareAndSwapUint32(&link.freq, freq, freq+1) {
+			return
+		}
+	}
+}
+
+func (q *fifoQueue) Len() int {
+	return q.list.Len()
+}
+
+// push puts h to the front with a zero frequency.
+func (q *fifoQueue) push(h *LRUHandle) {
+	h.link = &fifoLink{queue: q, element: q.list.PushFront(h), size: h.size}
+	q.size += h.size
+}
+
+func (q *fifoQueue) remove(h *LRUHandle) {
+	link := fifoLinkOf(h)
+	q.list.Remove(link.element)
+	q.size -= link.size
+	h.link = nil
+}
+
+// replace puts h to the place of old, the frequency is kept.
+func (q *fifoQueue) replace(old, h *LRUHandle) {
+	link := fifoLinkOf(old)
+	link.element.Value = h
+	h.link, old.link = link, nil
+	q.resized(h)
+}
+
+// resized updates the size of the queue after the size of h changed.
+func (q *fifoQueue) resized(h *LRUHandle) {
+	link := fifoLinkOf(h)
+	q.size += h.size - link.size
+	link.size = h.size
+}
+
+func (q *fifoQueue) front() *LRUHandle {
+	if e := q.list.Front(); e != nil {
+		return e.Value.(*LRUHandle)
+	}
+	return nil
+}
+
+func (q *fifoQueue) back() *LRUHandle {
+	if e := q.list.Back(); e != nil {
+		return e.Value.(*LRUHandle)
+	}
+	return nil
+}
+
+// appendKeys appends the keys from the newest to the oldest.
+func (q *fifoQueue) appendKeys(keys []string) []string {
+	for e := q.list.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(*LRUHandle).key)
+	}
+	return keys
+}
+
+func (q *fifoQueue) clear() {
+	q.list.Init()
+	q.size = 0
+}
@@ -2,4 +2,6 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-module "github.com/chai2010/cache"
+module github.com/chai2010/cache
+
+go 1.21
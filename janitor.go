@@ -0,0 +1,152 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+// DefaultJanitorBatch is the max number of entries examined by the
+// janitor with the lock held.
+const DefaultJanitorBatch = 1024
+
+// janitor removes the stale entries periodically in the background.
+type janitor struct {
+	auto bool // started by SetIdleTimeout
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newJanitor(interval time.Duration, pass func()) *janitor {
+	assert(interval > 0)
+
+	j := &janitor{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.stop:
+				return
+			case <-ticker.C:
+				pass()
+			}
+		}
+	}()
+	return j
+}
+
+// Stop stops the janitor and waits for the running pass to return.
+func (j *janitor) Stop() {
+	close(j.stop)
+	<-j.done
+}
+
+// StartJanitor starts a background goroutine which removes the expired
+// and idle entries every interval.  Each pass scans the list from the
+// back, and examines at most batch entries with the lock held.  If
+// report is not nil, it will be called with the number of entries
+// removed by each pass.
+//
+// The janitor is stopped by StopJanitor or Close.
+func (p *LRUCache) StartJanitor(interval time.Duration, batch int, report func(removed int)) {
+	p.startJanitor(interval, batch, report, false)
+}
+
+// StopJanitor stops the background janitor, and waits for the running
+// pass to return.
+func (p *LRUCache) StopJanitor() {
+	p.mu.Lock()
+	j := p.janitor
+	p.janitor = nil
+	p.mu.Unlock()
+
+	if j != nil {
+		j.Stop()
+	}
+}
+
+func (p *LRUCache) startJanitor(interval time.Duration, batch int, report func(removed int), auto bool) {
+	assert(interval > 0 && batch > 0)
+
+	// the janitor holds only the inner cache, so the unreachable
+	// LRUCache can be finalized, and its finalizer stops the janitor
+	c := p._LRUCache
+	j := newJanitor(interval, func() {
+		n := c.removeExpired(batch)
+		if report != nil {
+			report(n)
+		}
+	})
+	j.auto = auto
+
+	p.mu.Lock()
+	old := p.janitor
+	p.janitor = j
+	p.mu.Unlock()
+
+	if old != nil {
+		old.Stop()
+	}
+}
+
+// removeExpired removes the stale entries from the back of the list,
+// and releases the lock after every batch entries examined.  A
+// non-positive batch means no limit.
+func (p *_LRUCache) removeExpired(batch int) (n int) {
+	var (
+		cursor    *list.Element // next element to examine
+		cursorKey string
+		remain    = -1 // entries not examined yet
+	)
+
+	for {
+		p.mu.Lock()
+		if p.list == nil {
+			p.mu.Unlock()
+			return
+		}
+
+		if remain < 0 {
+			remain = p.list.Len()
+		}
+
+		// restart from the back if the cursor has been removed
+		e := cursor
+		if e == nil || p.table[cursorKey] != e {
+			e = p.list.Back()
+		}
+
+		now := p.clock.Now()
+		for i := 0; e != nil && remain > 0 && (batch <= 0 || i < batch); i++ {
+			prev := e.Prev()
+			if h := e.Value.(*LRUHandle); p.stale(h, now) {
+				p.list.Remove(e)
+				delete(p.table, h.key)
+				p.evicted(h, EvictExpired)
+				p.unref(h)
+				n++
+			}
+			e, remain = prev, remain-1
+		}
+
+		cursor = e
+		if e != nil {
+			cursorKey = e.Value.(*LRUHandle).key
+		}
+		p.mu.Unlock()
+
+		if e == nil || remain <= 0 {
+			return
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLRUCache_removeExpiredBatch(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	c := tNewTCache(tCacheSize, WithClock(clock))
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		ttl := time.Hour
+		if i%2 == 0 {
+			ttl = time.Minute
+		}
+		c.LRUCache.SetWithTTL(strconv.Itoa(i), 1000+i, 1, ttl, c.onDeleter)
+	}
+	clock.Advance(time.Minute)
+
+	tAssertEQ(t, 5, c.removeExpired(3))
+	tAssertEQ(t, int64(5), c.Length())
+	tAssertEQ(t, 5, len(c.deleted_keys_))
+	tAssertEQ(t, 0, c.removeExpired(3))
+}
+
+func TestLRUCache_janitor(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	c := tNewTCache(tCacheSize, WithClock(clock))
+	defer c.Close()
+
+	removed := make(chan int, 100)
+	c.StartJanitor(5*time.Millisecond, 2, func(n int) {
+		select {
+		case removed <- n:
+		default:
+		}
+	})
+
+	for i := 0; i < 10; i++ {
+		c.LRUCache.SetWithTTL(strconv.Itoa(i), 1000+i, 1, time.Minute, c.onDeleter)
+	}
+	clock.Advance(time.Minute)
+
+	total := 0
+	for total < 10 {
+		select {
+		case n := <-removed:
+			total += n
+		case <-time.After(time.Second):
+			t.Fatalf("janitor timeout, removed = %d", total)
+		}
+	}
+	tAssertEQ(t, 10, total)
+
+	// no report after stopped
+	c.StopJanitor()
+	for len(removed) > 0 {
+		<-removed
+	}
+	time.Sleep(20 * time.Millisecond)
+	tAssertEQ(t, 0, len(removed))
+}
+
+func TestLRUCache_janitorFinalizer(t *testing.T) {
+	runtime.GC()
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 50; i++ {
+		NewLRUCache(10, WithIdleTimeout(time.Hour))
+	}
+	tAssertTrue(t, runtime.NumGoroutine() >= before+50)
+
+	// the unclosed caches are finalized, and their janitors stopped
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	tAssertTrue(t, runtime.NumGoroutine() <= before, runtime.NumGoroutine(), before)
+}
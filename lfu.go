@@ -0,0 +1,140 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"container/list"
+)
+
+// assert match interface
+var _ Cache = (*LFUCache)(nil)
+
+// LFUCache is a LFU cache implementation.  If the cache reaches the
+// capacity, the least frequently used item is deleted from the cache,
+// and the least recently used one is deleted if several items have
+// the same frequency.  All the operations are O(1).
+//
+// Note the capacity is not the number of items, but the total sum of
+// the Size() of each item.
+type LFUCache struct {
+	*policyCache
+}
+
+// NewLFUCache creates a new empty LFU cache with the given capacity.
+// The WithTTL and WithIdleTimeout options are not supported.
+func NewLFUCache(capacity int64, opts ...Option) *LFUCache {
+	return &LFUCache{newPolicyCache(capacity, newLFUPolicy(), opts...)}
+}
+
+// lfuPolicy keeps the entries in the buckets of the same frequency,
+// the buckets are ordered by the frequency in ascending order, and the
+// entries in a bucket are ordered from most recently used to least
+// recently used.
+type lfuPolicy struct {
+	buckets *list.List // of *lfuBucket
+}
+
+type lfuBucket struct {
+	freq    uint64
+	entries *list.List // of *LRUHandle
+}
+
+// lfuLink is the LRUHandle.link of lfuPolicy.
+type lfuLink struct {
+	bucket  *list.Element
+	element *list.Element
+}
+
+func newLFUPolicy() *lfuPolicy {
+	return &lfuPolicy{buckets: list.New()}
+}
+
+// Freq returns the access frequency of the entry of key, or 0 if
+// the cache has no mapping for key.
+func (p *LFUCache) Freq(key string) uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if h := p.table[key]; h != nil {
+		return h.link.(*lfuLink).bucket.Value.(*lfuBucket).freq
+	}
+	return 0
+}
+
+func (p *lfuPolicy) add(h *LRUHandle) {
+	bucket := p.buckets.Front()
+	if bucket == nil || bucket.Value.(*lfuBucket).freq != 1 {
+		bucket = p.buckets.PushFront(&lfuBucket{freq: 1, entries: list.New()})
+	}
+	h.link = &lfuLink{
+		bucket:  bucket,
+		element: bucket.Value.(*lfuBucket).entries.PushFront(h),
+	}
+}
+
+func (p *lfuPolicy) hit(h *LRUHandle) {
+	link := h.link.(*lfuLink)
+	b := link.bucket.Value.(*lfuBucket)
+
+	next := link.bucket.Next()
+	if next == nil || next.Value.(*lfuBucket).freq != b.freq+1 {
+		next = p.buckets.InsertAfter(&lfuBucket{freq: b.freq + 1, entries: list.New()}, link.bucket)
+	}
+
+	p.unlink(link)
+	link.bucket = next
+	link.element = next.Value.(*lfuBucket).entries.PushFront(h)
+}
+
+func (p *lfuPolicy) remove(h *LRUHandle) {
+	p.unlink(h.link.(*lfuLink))
+	h.link = nil
+}
+
+// replace puts h to the place of old, so the frequency is kept.
+func (p *lfuPolicy) replace(old, h *LRUHandle) {
+	link := old.link.(*lfuLink)
+	link.element.Value = h
+	h.link, old.link = link, nil
+}
+
+// unlink removes the entry from its bucket, and removes the bucket
+// if it becomes empty.
+func (p *lfuPolicy) unlink(link *lfuLink) {
+	b := link.bucket.Value.(*lfuBucket)
+	b.entries.Remove(link.element)
+	if b.entries.Len() == 0 {
+		p.buckets.Remove(link.bucket)
+	}
+}
+
+func (p *lfuPolicy) front() *LRUHandle {
+	if bucket := p.buckets.Back(); bucket != nil {
+		return bucket.Value.(*lfuBucket).entries.Front().Value.(*LRUHandle)
+	}
+	return nil
+}
+
+func (p *lfuPolicy) oldest() *LRUHandle {
+	if bucket := p.buckets.Front(); bucket != nil {
+		return bucket.Value.(*lfuBucket).entries.Back().Value.(*LRUHandle)
+	}
+	return nil
+}
+
+// appendKeys appends the keys ordered from most frequently used to
+// least frequently used.
+func (p *lfuPolicy) appendKeys(keys []string) []string {
+	for bucket := p.buckets.Back(); bucket != nil; bucket = bucket.Prev() {
+		for e := bucket.Value.(*lfuBucket).entries.Front(); e != nil; e = e.Next() {
+			keys = append(keys, e.Value.(*LRUHandle).key)
+		}
+	}
+	return keys
+}
+
+func (p *lfuPolicy) clear() {
+	p.buckets.Init()
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"testing"
+)
+
+func TestLFUCache(t *testing.T) {
+	var deleted []string
+	c := NewLFUCache(3, WithDeleter(func(key string, value interface{}) {
+		deleted = append(deleted, key)
+	}))
+	defer c.Close()
+
+	c.Set("a", 1, 1)
+	c.Set("b", 2, 1)
+	c.Set("c", 3, 1)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	tAssertEQ(t, uint64(3), c.Freq("a"))
+	tAssertEQ(t, uint64(2), c.Freq("b"))
+	tAssertEQ(t, uint64(1), c.Freq("c"))
+	tAssertEQ(t, []string{"a", "b", "c"}, c.Keys())
+
+	// the least frequently used is evicted, not the new one
+	c.Set("d", 4, 1)
+	tAssertEQ(t, []string{"c"}, deleted)
+	tAssertEQ(t, []string{"a", "b", "d"}, c.Keys())
+
+	// same frequency, the least recently used is evicted
+	c.Get("d")
+	c.Set("e", 5, 1)
+	tAssertEQ(t, []string{"c", "b"}, deleted)
+	tAssertEQ(t, []string{"a", "d", "e"}, c.Keys())
+
+	tAssertEQ(t, "a", c.FrontKey())
+	tAssertEQ(t, "e", c.BackKey())
+	tAssertEQ(t, 5, c.BackValue())
+
+	c.RemoveBack()
+	tAssertEQ(t, []string{"c", "b", "e"}, deleted)
+	tAssertEQ(t, int64(2), c.Length())
+	tAssertEQ(t, uint64(0), c.Freq("e"))
+
+	c.Erase("a")
+	tAssertEQ(t, []string{"d"}, c.Keys())
+	tAssertEQ(t, uint64(2), c.CacheStats().Evictions)
+}
+
+func TestLFUCache_weighted(t *testing.T) {
+	c := NewLFUCache(10)
+	defer c.Close()
+
+	c.Set("a", 1, 4)
+	c.Set("b", 2, 4)
+	c.Get("a")
+	c.Set("c", 3, 4)
+	tAssertEQ(t, []string{"a", "c"}, c.Keys())
+	tAssertEQ(t, int64(8), c.Size())
+
+	// the resized entry may be the victim
+	tAssertNil(t, c.Resize("c", 8))
+	tAssertEQ(t, []string{"a"}, c.Keys())
+	tAssertEQ(t, int64(4), c.Size())
+	tAssertTrue(t, c.Resize("x", 1) == ErrNotFound)
+}
+
+func TestLFUCache_replace(t *testing.T) {
+	var deleted []string
+	c := NewLFUCache(3, WithDeleter(func(key string, value interface{}) {
+		deleted = append(deleted, key)
+	}))
+	defer c.Close()
+
+	c.Set("hot", 0, 1)
+	for i := 0; i < 100; i++ {
+		c.Get("hot")
+	}
+	tAssertEQ(t, uint64(101), c.Freq("hot"))
+
+	// the updated entry keeps its frequency, and the set is a hit
+	c.Set("hot", 1, 2)
+	tAssertEQ(t, uint64(102), c.Freq("hot"))
+	tAssertEQ(t, 1, c.Value("hot"))
+	tAssertEQ(t, []string{"hot"}, deleted)
+	tAssertEQ(t, int64(2), c.Size())
+
+	c.Set("a", 1, 1)
+	c.Get("a")
+	c.Set("b", 2, 1)
+	tAssertEQ(t, []string{"hot", "a"}, deleted)
+	tAssertEQ(t, []string{"hot", "b"}, c.Keys())
+	tAssertEQ(t, 1, c.Value("hot"))
+}
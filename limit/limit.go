@@ -60,6 +60,17 @@ func (p *Opener) Close() error {
 	return nil
 }
 
+// StatsSnapshot returns the stats of the opened files cache.
+func (p *Opener) StatsSnapshot() cache.Stats {
+	return p.cache.StatsSnapshot()
+}
+
+// StatsJSON returns the stats of the opened files cache as a JSON
+// object in a string.
+func (p *Opener) StatsJSON() string {
+	return p.cache.StatsJSON()
+}
+
 func (p *Opener) Open(name string) (f interface{}, h io.Closer, err error) {
 	assert(name != "")
 
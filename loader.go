@@ -0,0 +1,116 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// loadCall is an in-flight or completed load of GetFrom.
+type loadCall struct {
+	done  chan struct{} // closed when the load completed
+	dups  int           // number of the waiters
+	value interface{}
+	err   error
+}
+
+// beginLoad returns the in-flight load of key, or a new one if there
+// is none, leader reports whether the caller must do the new load.
+func (p *LRUCache) beginLoad(key string) (call *loadCall, leader bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if call = p.loads[key]; call != nil {
+		call.dups++
+		p.stats.loadWait()
+		return call, false
+	}
+
+	call = &loadCall{done: make(chan struct{})}
+	if p.loads == nil {
+		p.loads = make(map[string]*loadCall)
+	}
+	p.loads[key] = call
+	return call, true
+}
+
+// doLoad runs the getter, puts the value to the cache and wakes up
+// all the waiters of the call.  If the getter panics, the waiters get
+// an error of the panic.  The panic of a detached load (which runs in
+// its own goroutine) is recovered, else it goes on to the caller.
+func (p *LRUCache) doLoad(key string, call *loadCall, getter func(key string) (v interface{}, size int, err error), detached bool) {
+	finished := false
+	defer func() {
+		if !finished {
+			if detached {
+				call.err = fmt.Errorf("cache: load %q panicked: %v", key, recover())
+			} else {
+				call.err = fmt.Errorf("cache: load %q panicked!", key)
+			}
+			call.value = nil
+			p.stats.loadError()
+		}
+
+		p.mu.Lock()
+		delete(p.loads, key)
+		p.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	value, size, err := getter(key)
+	if err == nil {
+		var h io.Closer
+		if h, err = p.TryInsert(key, value, size, nil); err == nil {
+			h.Close()
+		} else {
+			value = nil
+		}
+	}
+	if err != nil {
+		p.stats.loadError()
+	}
+	call.value, call.err = value, err
+	finished = true
+}
+
+// GetFromContext same as GetFrom, but the loader receives a context.
+//
+// Concurrent misses of the same key share the same load.  If ctx is
+// done before the load completed, GetFromContext returns ctx.Err()
+// immediately, but the shared load keeps running for the other
+// waiters and its value is still put to the cache.  So the loader
+// receives a context detached from the cancellation of ctx, which
+// only carries the values of ctx.  If the loader panics, all the
+// waiters get an error of the panic.
+func (p *LRUCache) GetFromContext(ctx context.Context, key string, loader func(ctx context.Context, key string) (v interface{}, size int, err error)) (value interface{}, err error) {
+	if v, h, ok := p.Lookup(key); ok {
+		h.Close()
+		return v, nil
+	}
+	if loader == nil {
+		return nil, fmt.Errorf("cache: %q not found!", key)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	call, leader := p.beginLoad(key)
+	if leader {
+		detached := context.WithoutCancel(ctx)
+		go p.doLoad(key, call, func(key string) (interface{}, int, error) {
+			return loader(detached, key)
+		}, true)
+	}
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
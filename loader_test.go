@@ -0,0 +1,234 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLRUCache_GetFrom_singleflight(t *testing.T) {
+	c := NewLRUCache(tCacheSize)
+	defer c.Close()
+
+	const N = 10
+	var calls int32
+	start := make(chan struct{})
+	getter := func(key string) (interface{}, int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-start
+		return "value:" + key, 1, nil
+	}
+
+	type loadResult struct {
+		value interface{}
+		err   error
+	}
+	results := make(chan loadResult, N)
+	for i := 0; i < N; i++ {
+		go func() {
+			v, err := c.GetFrom("key", getter)
+			results <- loadResult{v, err}
+		}()
+	}
+
+	// wait all the waiters blocked on the first load
+	for i := 0; i < 1000 && c.tLoadWaiters("key") < N-1; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	close(start)
+	for i := 0; i < N; i++ {
+		r := <-results
+		tAssertNil(t, r.err)
+		tAssertEQ(t, "value:key", r.value)
+	}
+
+	tAssertEQ(t, int32(1), atomic.LoadInt32(&calls))
+	tAssertEQ(t, uint64(N-1), c.CacheStats().LoadWaits)
+	tAssertEQ(t, "value:key", c.Value("key"))
+}
+
+func TestLRUCache_GetFrom_sharedError(t *testing.T) {
+	c := NewLRUCache(tCacheSize)
+	defer c.Close()
+
+	errLoad := errors.New("load failed")
+	start := make(chan struct{})
+	getter := func(key string) (interface{}, int, error) {
+		<-start
+		return nil, 0, errLoad
+	}
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := c.GetFrom("key", getter)
+			errs <- err
+		}()
+	}
+	for i := 0; i < 1000 && c.tLoadWaiters("key") < 1; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	close(start)
+	for i := 0; i < 2; i++ {
+		tAssertTrue(t, <-errs == errLoad)
+	}
+
+	tAssertFalse(t, c.HasKey("key"))
+
+	// the failed load is not cached
+	v, err := c.GetFrom("key", func(key string) (interface{}, int, error) {
+		return "ok", 1, nil
+	})
+	tAssertNil(t, err)
+	tAssertEQ(t, "ok", v)
+}
+
+func TestLRUCache_GetFromContext(t *testing.T) {
+	c := NewLRUCache(tCacheSize)
+	defer c.Close()
+
+	type ctxKey struct{}
+
+	start := make(chan struct{})
+	loaded := make(chan context.Context, 1)
+	loader := func(ctx context.Context, key string) (interface{}, int, error) {
+		<-start
+		loaded <- ctx
+		return "value:" + key, 1, nil
+	}
+
+	// the first waiter abandons the wait
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "v"))
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.GetFromContext(ctx, "key", loader)
+		done <- err
+	}()
+	for i := 0; i < 1000 && !c.tHasLoad("key"); i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the second waiter shares the load
+	type loadResult struct {
+		value interface{}
+		err   error
+	}
+	result := make(chan loadResult, 1)
+	go func() {
+		v, err := c.GetFromContext(context.Background(), "key", loader)
+		result <- loadResult{v, err}
+	}()
+	for i := 0; i < 1000 && c.tLoadWaiters("key") < 1; i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	tAssertTrue(t, <-done == context.Canceled)
+
+	// the shared load is not cancelled
+	close(start)
+	loadCtx := <-loaded
+	tAssertNil(t, loadCtx.Err())
+	tAssertEQ(t, "v", loadCtx.Value(ctxKey{}))
+	r := <-result
+	tAssertNil(t, r.err)
+	tAssertEQ(t, "value:key", r.value)
+	tAssertEQ(t, "value:key", c.Value("key"))
+
+	// canceled context
+	_, err := c.GetFromContext(ctx, "other", loader)
+	tAssertTrue(t, err == context.Canceled)
+}
+
+func TestLRUCache_GetFromContext_panic(t *testing.T) {
+	c := NewLRUCache(tCacheSize)
+	defer c.Close()
+
+	start := make(chan struct{})
+	loader := func(ctx context.Context, key string) (interface{}, int, error) {
+		<-start
+		panic("bad loader")
+	}
+
+	const N = 3
+	errs := make(chan error, N)
+	for i := 0; i < N; i++ {
+		go func() {
+			_, err := c.GetFromContext(context.Background(), "key", loader)
+			errs <- err
+		}()
+	}
+	for i := 0; i < 1000 && c.tLoadWaiters("key") < N-1; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	close(start)
+
+	// all the waiters get the panic as the error
+	for i := 0; i < N; i++ {
+		err := <-errs
+		tAssertTrue(t, err != nil)
+		tAssertTrue(t, strings.Contains(err.Error(), "bad loader"), err)
+	}
+	tAssertFalse(t, c.HasKey("key"))
+	tAssertFalse(t, c.tHasLoad("key"))
+	tAssertEQ(t, uint64(1), c.CacheStats().LoadErrors)
+}
+
+func TestLRUCache_GetFrom_panic(t *testing.T) {
+	c := NewLRUCache(tCacheSize)
+	defer c.Close()
+
+	start := make(chan struct{})
+	getter := func(key string) (interface{}, int, error) {
+		<-start
+		panic("bad getter")
+	}
+
+	// the leader loads the value on the caller's goroutine
+	leader := make(chan interface{}, 1)
+	go func() {
+		defer func() { leader <- recover() }()
+		c.GetFrom("key", getter)
+	}()
+	for i := 0; i < 1000 && !c.tHasLoad("key"); i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.GetFrom("key", getter)
+		errs <- err
+	}()
+	for i := 0; i < 1000 && c.tLoadWaiters("key") < 1; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	close(start)
+
+	// the panic goes on to the leader, and the waiter gets an error
+	tAssertEQ(t, "bad getter", <-leader)
+	err := <-errs
+	tAssertTrue(t, err != nil && strings.Contains(err.Error(), "panicked"), err)
+	tAssertFalse(t, c.tHasLoad("key"))
+}
+
+func (p *LRUCache) tHasLoad(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.loads[key] != nil
+}
+
+func (p *LRUCache) tLoadWaiters(key string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if call := p.loads[key]; call != nil {
+		return call.dups
+	}
+	return 0
+}
@@ -39,20 +39,59 @@ type _LRUCache struct {
 	// How much we are limiting the cache to.
 	capacity int64
 
+	// Max number of the entries, 0 means no limit.
+	max_entries int64
+
+	// default deleter for the entries without deleter
+	deleter func(key string, value interface{})
+
+	// called when an entry leaves the table
+	on_evict func(key string, value interface{}, reason EvictReason)
+
+	// in-flight GetFrom calls
+	loads map[string]*loadCall
+
+	// nil if the stats collection is disabled
+	stats *cacheCounters
+
+	// source of the current time
+	clock Clock
+
+	// Default time to live of the new entries, 0 means never expire.
+	ttl time.Duration
+
+	// Max idle time since the last access, 0 means never expire.
+	idle time.Duration
+
+	// background goroutine to remove the stale entries
+	janitor *janitor
+
 	// for next id
 	last_id uint64
 }
 
-// LRUHandle handle to an entry stored in the LRUCache.
+// LRUHandle handle to an entry stored in the LRUCache, it is also used
+// by the other caches of this package.
 type LRUHandle struct {
-	c             *LRUCache
+	c             handleOwner
 	key           string
 	value         interface{}
 	size          int64
 	deleter       func(key string, value interface{})
 	time_created  time.Time
 	time_accessed atomic.Value // time.Time
+	time_expires  time.Time    // zero means never expire
 	refs          uint32
+	link          interface{} // private data of the eviction policy
+}
+
+// handleOwner is the cache which owns the LRUHandle.
+type handleOwner interface {
+	lock()
+	unlock()
+	addref(h *LRUHandle)
+	release(h *LRUHandle)
+	resize(h *LRUHandle, size int64)
 }
 
 func (h *LRUHandle) Key() string {
@@ -64,8 +103,24 @@ func (h *LRUHandle) Value() interface{} {
 }
 
 func (h *LRUHandle) Size() int {
-	return int(h.size)
+	return int(atomic.LoadInt64(&h.size))
 }
+
+// SetSize updates the size of the entry in place, without changing the
+// identity or the LRU position of the entry.  If the cache exceed the
+// capacity, the least recently used entries (may include this one) are
+// evicted.
+func (h *LRUHandle) SetSize(size int) error {
+	if size <= 0 {
+		return ErrInvalidSize
+	}
+
+	h.c.lock()
+	defer h.c.unlock()
+	h.c.resize(h, int64(size))
+	return nil
+}
+
 func (h *LRUHandle) TimeCreated() time.Time {
 	return h.time_created
 }
@@ -74,41 +129,90 @@ func (h *LRUHandle) TimeAccessed() time.Time {
 	return h.time_accessed.Load().(time.Time)
 }
 
+// TimeExpires returns the deadline of the entry, or a IsZero() time
+// if the entry never expires.
+func (h *LRUHandle) TimeExpires() time.Time {
+	return h.time_expires
+}
+
+func (h *LRUHandle) expired(now time.Time) bool {
+	return !h.time_expires.IsZero() && !now.Before(h.time_expires)
+}
+
 func (h *LRUHandle) Retain() (handle *LRUHandle) {
-	h.c.mu.Lock()
-	defer h.c.mu.Unlock()
+	h.c.lock()
+	defer h.c.unlock()
 	h.c.addref(h)
 	return h
 }
 
 func (h *LRUHandle) Close() error {
-	h.c.mu.Lock()
-	defer h.c.mu.Unlock()
-	h.c.unref(h)
+	h.c.release(h)
 	return nil
 }
 
 // NewLRUCache creates a new empty cache with the given capacity.
-func NewLRUCache(capacity int64) *LRUCache {
-	assert(capacity > 0)
+func NewLRUCache(capacity int64, opts ...Option) *LRUCache {
+	return NewLRUCacheWithOptions(append([]Option{WithCapacity(capacity)}, opts...)...)
+}
+
+// NewLRUCacheWithOptions creates a new empty cache with the options,
+// WithCapacity is required.
+func NewLRUCacheWithOptions(opts ...Option) *LRUCache {
+	o := newOptions(opts...)
+	assert(o.capacity > 0)
+
 	p := &_LRUCache{
-		list:     list.New(),
-		table:    make(map[string]*list.Element),
-		capacity: capacity,
+		list:        list.New(),
+		table:       make(map[string]*list.Element),
+		capacity:    o.capacity,
+		max_entries: o.max_entries,
+		deleter:     o.deleter,
+		on_evict:    o.on_evict,
+		stats:       newCacheCounters(o.stats),
+		clock:       o.clock,
+		ttl:         o.ttl,
 	}
-	runtime.SetFinalizer(p, (*_LRUCache).Close)
-	return &LRUCache{p}
+	c := &LRUCache{p}
+	runtime.SetFinalizer(c, (*LRUCache).Close)
+	if o.idle > 0 {
+		c.SetIdleTimeout(o.idle)
+	}
+	return c
+}
+
+// TryNewLRUCache same as NewLRUCache, but returns ErrInvalidCapacity
+// instead of panic if the capacity is not positive.
+func TryNewLRUCache(capacity int64, opts ...Option) (*LRUCache, error) {
+	opts = append([]Option{WithCapacity(capacity)}, opts...)
+	if o := newOptions(opts...); o.capacity <= 0 {
+		return nil, ErrInvalidCapacity
+	}
+	return NewLRUCacheWithOptions(opts...), nil
 }
 
 // Destroys all existing entries by calling the "deleter"
 // function that was passed to the constructor.
 // REQUIRES: all handles must have been released.
 func (p *LRUCache) Close() error {
-	runtime.SetFinalizer(p._LRUCache, nil)
+	p.StopJanitor()
+	runtime.SetFinalizer(p, nil)
 	p._LRUCache.Close()
 	return nil
 }
 
+// CloseErr same as Close, but returns ErrHandlesOutstanding and keeps
+// the cache unchanged instead of panic if some handles have not been
+// released.
+func (p *LRUCache) CloseErr() error {
+	if err := p._LRUCache.tryClose(); err != nil {
+		return err
+	}
+	p.StopJanitor()
+	runtime.SetFinalizer(p, nil)
+	return nil
+}
+
 func (p *LRUCache) Get(key string) (value interface{}, ok bool) {
 	if v, h, ok := p.Lookup(key); ok {
 		h.Close()
@@ -125,13 +229,15 @@ func (p *LRUCache) GetFrom(key string, getter func(key string) (v interface{}, s
 	if getter == nil {
 		return nil, fmt.Errorf("cache: %q not found!", key)
 	}
-	value, size, err := getter(key)
-	if err != nil {
-		return
+
+	// concurrent misses of the same key share the same load
+	call, leader := p.beginLoad(key)
+	if leader {
+		p.doLoad(key, call, getter, false)
+	} else {
+		<-call.done
 	}
-	assert(size > 0)
-	p.Set(key, value, size)
-	return
+	return call.value, call.err
 }
 
 func (p *LRUCache) Value(key string, defaultValue ...interface{}) interface{} {
@@ -156,6 +262,17 @@ func (p *LRUCache) Set(key string, value interface{}, size int, deleter ...func(
 	}
 }
 
+// SetWithTTL same as Set, but the entry expires after ttl.
+func (p *LRUCache) SetWithTTL(key string, value interface{}, size int, ttl time.Duration, deleter ...func(key string, value interface{})) {
+	if len(deleter) > 0 {
+		h := p.InsertWithTTL(key, value, size, ttl, deleter[0])
+		h.Close()
+	} else {
+		h := p.InsertWithTTL(key, value, size, ttl, nil)
+		h.Close()
+	}
+}
+
 // Return a new numeric id.  May be used by multiple clients who are
 // sharing the same cache to partition the key space.  Typically the
 // client will allocate a new id at startup and prepend the id to
@@ -187,29 +304,75 @@ func (p *LRUCache) Insert_(key string, value interface{}, size int, deleter func
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	return p.insert(key, value, size, deleter, p.ttl, 2, true) // One from LRUCache, one for the returned handle
+}
+
+// TryInsert same as Insert, but returns ErrInvalidKey or ErrInvalidSize
+// instead of panic if the key is empty or the size is not positive.
+func (p *LRUCache) TryInsert(key string, value interface{}, size int, deleter func(key string, value interface{})) (handle io.Closer, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := checkEntry(key, size); err != nil {
+		return nil, err
+	}
+	return p.insert(key, value, size, deleter, p.ttl, 2, true), nil
+}
+
+// InsertWithTTL same as Insert, but the entry expires after ttl.
+//
+// An expired entry is invisible to Lookup and Get, and the deleter
+// is invoked after all existing handles to it have been released.
+// A non-positive ttl means the entry never expires.
+func (p *LRUCache) InsertWithTTL(key string, value interface{}, size int, ttl time.Duration, deleter func(key string, value interface{})) (handle io.Closer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.insert(key, value, size, deleter, ttl, 2, true)
+}
+
+// insert a new entry to the front (or back) of the list.
+// REQUIRES: p.mu must be held.
+func (p *LRUCache) insert(key string, value interface{}, size int, deleter func(key string, value interface{}), ttl time.Duration, refs uint32, front bool) (handle *LRUHandle) {
 	assert(key != "" && size > 0)
 	if element := p.table[key]; element != nil {
 		p.list.Remove(element)
 		delete(p.table, key)
 
 		h := element.Value.(*LRUHandle)
+		p.evicted(h, EvictReplaced)
 		p.unref(h)
+		p.stats.replace()
 	}
 
+	if deleter == nil {
+		deleter = p.deleter
+	}
+
+	now := p.clock.Now()
 	h := &LRUHandle{
 		c:            p,
 		key:          key,
 		value:        value,
 		size:         int64(size),
 		deleter:      deleter,
-		time_created: time.Now(),
-		refs:         2, // One from LRUCache, one for the returned handle
+		time_created: now,
+		refs:         refs,
+	}
+	h.time_accessed.Store(now)
+	if ttl > 0 {
+		h.time_expires = now.Add(ttl)
 	}
-	h.time_accessed.Store(time.Now())
 
-	element := p.list.PushFront(h)
+	var element *list.Element
+	if front {
+		element = p.list.PushFront(h)
+	} else {
+		element = p.list.PushBack(h)
+	}
 	p.table[key] = element
 	p.size += h.size
+	p.stats.insert()
 	p.checkCapacity()
 	return h
 }
@@ -232,15 +395,18 @@ func (p *LRUCache) Lookup_(key string) (value interface{}, handle *LRUHandle, ok
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	element := p.table[key]
+	now := p.clock.Now()
+	element := p.element(key, now)
 	if element == nil {
+		p.stats.miss()
 		return nil, nil, false
 	}
 
 	p.list.MoveToFront(element)
 	h := element.Value.(*LRUHandle)
-	h.time_accessed.Store(time.Now())
+	h.time_accessed.Store(now)
 	p.addref(h)
+	p.stats.hit()
 	return h.Value(), h, true
 }
 
@@ -253,7 +419,7 @@ func (p *LRUCache) Take(key string) (handle io.Closer, ok bool) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	element := p.table[key]
+	element := p.element(key, p.clock.Now())
 	if element == nil {
 		return nil, false
 	}
@@ -262,6 +428,7 @@ func (p *LRUCache) Take(key string) (handle io.Closer, ok bool) {
 	delete(p.table, key)
 
 	h := element.Value.(*LRUHandle)
+	p.evicted(h, EvictErased)
 	return h, true
 }
 
@@ -281,10 +448,31 @@ func (p *LRUCache) Erase(key string) {
 	delete(p.table, key)
 
 	h := element.Value.(*LRUHandle)
+	p.evicted(h, EvictErased)
 	p.unref(h)
+	p.stats.erase()
 	return
 }
 
+// Resize updates the size of the entry of key in place, see
+// LRUHandle.SetSize.  It returns ErrNotFound if the cache has no
+// mapping for key.
+func (p *LRUCache) Resize(key string, size int) error {
+	if size <= 0 {
+		return ErrInvalidSize
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	element := p.element(key, p.clock.Now())
+	if element == nil {
+		return ErrNotFound
+	}
+	p.resize(element.Value.(*LRUHandle), int64(size))
+	return nil
+}
+
 // SetCapacity will set the capacity of the cache. If the capacity is
 // smaller, and the current cache size exceed that capacity, the cache
 // will be shrank.
@@ -297,6 +485,103 @@ func (p *LRUCache) SetCapacity(capacity int64) {
 	p.checkCapacity()
 }
 
+// TrySetCapacity same as SetCapacity, but returns ErrInvalidCapacity
+// instead of panic if the capacity is not positive.
+func (p *LRUCache) TrySetCapacity(capacity int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if capacity <= 0 {
+		return ErrInvalidCapacity
+	}
+	p.capacity = capacity
+	p.checkCapacity()
+	return nil
+}
+
+// SetMaxEntries will set the max number of the entries, a non-positive
+// n means no limit.  If the current number of the entries exceed n,
+// the cache will be shrank.
+func (p *LRUCache) SetMaxEntries(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if n < 0 {
+		n = 0
+	}
+	p.max_entries = n
+	p.checkCapacity()
+}
+
+// MaxEntries returns the max number of the entries, 0 means no limit.
+func (p *LRUCache) MaxEntries() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.max_entries
+}
+
+// SetDefaultTTL will set the default time to live of the entries
+// inserted later by Insert, Set, PushFront and PushBack.  A
+// non-positive ttl means the entries never expire.
+func (p *LRUCache) SetDefaultTTL(ttl time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ttl < 0 {
+		ttl = 0
+	}
+	p.ttl = ttl
+}
+
+// DefaultTTL returns the default time to live of the new entries.
+func (p *LRUCache) DefaultTTL() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ttl
+}
+
+// SetIdleTimeout will set the max idle time of the entries.  An entry
+// which has not been accessed by Lookup for idle time is treated as
+// expired, and will be removed lazily on access and eagerly by the
+// janitor.  If no janitor is running, a janitor with interval idle/2
+// will be started.  A non-positive idle disables the idle timeout.
+func (p *LRUCache) SetIdleTimeout(idle time.Duration) {
+	if idle < 0 {
+		idle = 0
+	}
+
+	p.mu.Lock()
+	p.idle = idle
+	j := p.janitor
+	p.mu.Unlock()
+
+	if j != nil && !j.auto {
+		return
+	}
+	if idle > 0 {
+		interval := idle / 2
+		if interval <= 0 {
+			interval = idle
+		}
+		p.startJanitor(interval, DefaultJanitorBatch, nil, true)
+	} else if j != nil {
+		p.StopJanitor()
+	}
+}
+
+// IdleTimeout returns the max idle time of the entries.
+func (p *LRUCache) IdleTimeout() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.idle
+}
+
+// RemoveExpired removes all the expired and idle entries, and
+// returns the number of removed entries.
+func (p *LRUCache) RemoveExpired() (n int) {
+	return p.removeExpired(0)
+}
+
 // Stats returns a few stats on the cache.
 func (p *LRUCache) Stats() (length, size, capacity int64, oldest time.Time) {
 	p.mu.Lock()
@@ -307,20 +592,6 @@ func (p *LRUCache) Stats() (length, size, capacity int64, oldest time.Time) {
 	return int64(p.list.Len()), p.size, p.capacity, oldest
 }
 
-// StatsJSON returns stats as a JSON object in a string.
-func (p *LRUCache) StatsJSON() string {
-	if p == nil {
-		return "{}"
-	}
-	l, s, c, o := p.Stats()
-	return fmt.Sprintf(`{
-	"Length": %v,
-	"Size": %v,
-	"Capacity": %v,
-	"OldestAccess": "%v"
-}`, l, s, c, o)
-}
-
 // Length returns how many elements are in the cache
 func (p *LRUCache) Length() int64 {
 	p.mu.Lock()
@@ -377,10 +648,25 @@ func (p *LRUCache) Keys() []string {
 	return keys
 }
 
+func (p *_LRUCache) lock() {
+	p.mu.Lock()
+}
+
+func (p *_LRUCache) unlock() {
+	p.mu.Unlock()
+}
+
 func (p *_LRUCache) addref(h *LRUHandle) {
 	h.refs++
 }
 
+// release drops the reference of a handle.
+func (p *_LRUCache) release(h *LRUHandle) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unref(h)
+}
+
 func (p *_LRUCache) unref(h *LRUHandle) {
 	assert(h.refs > 0)
 	h.refs--
@@ -388,18 +674,66 @@ func (p *_LRUCache) unref(h *LRUHandle) {
 		p.size -= h.size
 		if h.deleter != nil {
 			h.deleter(h.key, h.value)
+			p.stats.delete()
 		}
 	}
 }
 
+// element returns the list element of key, the expired entry will
+// be erased and treated as missing.
+// REQUIRES: p.mu must be held.
+func (p *LRUCache) element(key string, now time.Time) *list.Element {
+	element := p.table[key]
+	if element == nil {
+		return nil
+	}
+
+	h := element.Value.(*LRUHandle)
+	if p.stale(h, now) {
+		p.list.Remove(element)
+		delete(p.table, key)
+		p.evicted(h, EvictExpired)
+		p.unref(h)
+		return nil
+	}
+	return element
+}
+
+// stale reports whether the entry is expired or idle too long.
+// REQUIRES: p.mu must be held.
+func (p *_LRUCache) stale(h *LRUHandle, now time.Time) bool {
+	if h.expired(now) {
+		return true
+	}
+	return p.idle > 0 && now.Sub(h.TimeAccessed()) >= p.idle
+}
+
+func (p *_LRUCache) overflow() bool {
+	if p.max_entries > 0 && int64(len(p.table)) > p.max_entries {
+		return true
+	}
+	return p.size > p.capacity
+}
+
+// resize updates the size of h, and shrinks the cache if necessary.
+// REQUIRES: p.mu must be held.
+func (p *LRUCache) resize(h *LRUHandle, size int64) {
+	assert(h.refs > 0)
+	p.size += size - h.size
+	atomic.StoreInt64(&h.size, size)
+	p.checkCapacity()
+}
+
 func (p *LRUCache) checkCapacity() {
 	// Partially duplicated from Delete
 	// must keep the front element valid!!!
-	for p.size > p.capacity && len(p.table) > 1 {
+	for p.overflow() && len(p.table) > 1 {
 		delElem := p.list.Back()
 		h := delElem.Value.(*LRUHandle)
 		p.list.Remove(delElem)
 		delete(p.table, h.key)
+		p.stats.evict()
+		p.evicted(h, EvictCapacity)
 		p.unref(h)
 	}
 }
@@ -412,6 +746,7 @@ func (p *LRUCache) Clear() {
 
 	for _, element := range p.table {
 		h := element.Value.(*LRUHandle)
+		p.evicted(h, EvictCleared)
 		p.unref(h)
 	}
 
@@ -428,6 +763,7 @@ func (p *_LRUCache) Close() {
 	for _, element := range p.table {
 		h := element.Value.(*LRUHandle)
 		assert(h.refs == 1, "h.refs = ", h.refs)
+		p.evicted(h, EvictClosed)
 		p.unref(h)
 	}
 
@@ -435,3 +771,27 @@ func (p *_LRUCache) Close() {
 	p.table = nil
 	p.size = 0
 }
+
+// tryClose same as Close, but returns ErrHandlesOutstanding if some
+// handles have not been released.
+func (p *_LRUCache) tryClose() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, element := range p.table {
+		if h := element.Value.(*LRUHandle); h.refs != 1 {
+			return ErrHandlesOutstanding
+		}
+	}
+
+	for _, element := range p.table {
+		h := element.Value.(*LRUHandle)
+		p.evicted(h, EvictClosed)
+		p.unref(h)
+	}
+
+	p.list = nil
+	p.table = nil
+	p.size = 0
+	return nil
+}
@@ -6,15 +6,13 @@ package cache
 
 import (
 	"container/list"
-	"time"
 )
 
 func (p *LRUCache) HasKey(key string) bool {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	_, ok := p.table[key]
-	return ok
+	return p.element(key, p.clock.Now()) != nil
 }
 
 func (p *LRUCache) FrontKey() (key string) {
@@ -105,62 +103,14 @@ func (p *LRUCache) PushFront(key string, value interface{}, size int, deleter fu
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	assert(key != "" && size > 0)
-	if element := p.table[key]; element != nil {
-		p.list.Remove(element)
-		delete(p.table, key)
-
-		h := element.Value.(*LRUHandle)
-		p.unref(h)
-	}
-
-	h := &LRUHandle{
-		c:            p,
-		key:          key,
-		value:        value,
-		size:         int64(size),
-		deleter:      deleter,
-		time_created: time.Now(),
-		refs:         1, // Only one from LRUCache, no returned handle
-	}
-	h.time_accessed.Store(time.Now())
-
-	element := p.list.PushFront(h)
-	p.table[key] = element
-	p.size += h.size
-	p.checkCapacity()
-	return
+	p.insert(key, value, size, deleter, p.ttl, 1, true) // Only one from LRUCache, no returned handle
 }
 
 func (p *LRUCache) PushBack(key string, value interface{}, size int, deleter func(key string, value interface{})) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	assert(key != "" && size > 0)
-	if element := p.table[key]; element != nil {
-		p.list.Remove(element)
-		delete(p.table, key)
-
-		h := element.Value.(*LRUHandle)
-		p.unref(h)
-	}
-
-	h := &LRUHandle{
-		c:            p,
-		key:          key,
-		value:        value,
-		size:         int64(size),
-		deleter:      deleter,
-		time_created: time.Now(),
-		refs:         1, // Only one from LRUCache, no returned handle
-	}
-	h.time_accessed.Store(time.Now())
-
-	element := p.list.PushBack(h)
-	p.table[key] = element
-	p.size += h.size
-	p.checkCapacity()
-	return
+	p.insert(key, value, size, deleter, p.ttl, 1, false) // Only one from LRUCache, no returned handle
 }
 
 func (p *LRUCache) PopBack() (h *LRUHandle) {
@@ -175,6 +125,7 @@ func (p *LRUCache) PopBack() (h *LRUHandle) {
 	h = element.Value.(*LRUHandle)
 	delete(p.table, h.Key())
 	p.list.Remove(element)
+	p.evicted(h, EvictErased)
 	return
 }
 
@@ -190,6 +141,7 @@ func (p *LRUCache) PopFront() (h *LRUHandle) {
 	h = element.Value.(*LRUHandle)
 	delete(p.table, h.Key())
 	p.list.Remove(element)
+	p.evicted(h, EvictErased)
 	return
 }
 
@@ -218,3 +170,29 @@ func (p *LRUCache) MoveToBack(key string) {
 	p.list.MoveToBack(element)
 	return
 }
+
+// TryPushFront same as PushFront, but returns ErrInvalidKey or
+// ErrInvalidSize instead of panic.
+func (p *LRUCache) TryPushFront(key string, value interface{}, size int, deleter func(key string, value interface{})) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := checkEntry(key, size); err != nil {
+		return err
+	}
+	p.insert(key, value, size, deleter, p.ttl, 1, true)
+	return nil
+}
+
+// TryPushBack same as PushBack, but returns ErrInvalidKey or
+// ErrInvalidSize instead of panic.
+func (p *LRUCache) TryPushBack(key string, value interface{}, size int, deleter func(key string, value interface{})) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := checkEntry(key, size); err != nil {
+		return err
+	}
+	p.insert(key, value, size, deleter, p.ttl, 1, false)
+	return nil
+}
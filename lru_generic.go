@@ -0,0 +1,329 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// assert match interface
+var _ TypedCache[string, int] = (*LRU[string, int])(nil)
+
+// LRU is the type-safe version of LRUCache.  If the cache reaches the
+// capacity, the least recently used item is deleted from the cache.
+// Note the capacity is not the number of items, but the total sum of
+// the Size() of each item.
+//
+// LRU is built on a LRUCache, every key in the cache is mapped to a
+// string id from NewId, so the keys are compared by == just like the
+// keys of map[K].
+type LRU[K comparable, V any] struct {
+	c *LRUCache
+
+	// the ids of the keys in the cache or being loaded, guarded by c.mu
+	ids map[K]*lruKeyId
+}
+
+// lruKeyId is the id of a key of the LRU in the LRUCache.
+type lruKeyId struct {
+	id      string
+	pending int // GetFrom calls using the id
+}
+
+// lruEntry is the value stored in the LRUCache for the LRU.
+type lruEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	deleter func(key K, value V)
+}
+
+// Handle is a typed handle to an entry stored in the LRU.
+type Handle[K comparable, V any] struct {
+	h *LRUHandle
+}
+
+func (h *Handle[K, V]) entry() *lruEntry[K, V] {
+	return h.h.value.(*lruEntry[K, V])
+}
+
+func (h *Handle[K, V]) Key() K {
+	return h.entry().key
+}
+
+func (h *Handle[K, V]) Value() V {
+	return h.entry().value
+}
+
+func (h *Handle[K, V]) Size() int {
+	return h.h.Size()
+}
+
+func (h *Handle[K, V]) TimeCreated() time.Time {
+	return h.h.TimeCreated()
+}
+
+func (h *Handle[K, V]) TimeAccessed() time.Time {
+	return h.h.TimeAccessed()
+}
+
+func (h *Handle[K, V]) Retain() (handle *Handle[K, V]) {
+	h.h.Retain()
+	return h
+}
+
+func (h *Handle[K, V]) Close() error {
+	return h.h.Close()
+}
+
+// lruDeleter is the deleter of the LRUCache entries with a typed deleter.
+func lruDeleter[K comparable, V any](_ string, value interface{}) {
+	e := value.(*lruEntry[K, V])
+	e.deleter(e.key, e.value)
+}
+
+// NewLRU creates a new empty typed cache with the given capacity.
+func NewLRU[K comparable, V any](capacity int64) *LRU[K, V] {
+	p := &LRU[K, V]{ids: make(map[K]*lruKeyId)}
+	p.c = NewLRUCache(capacity, WithEvictionListener(p.evicted))
+	return p
+}
+
+// keyId returns the id of key, a new id is allocated if key has none.
+// REQUIRES: p.c.mu must be held.
+func (p *LRU[K, V]) keyId(key K) *lruKeyId {
+	k := p.ids[key]
+	if k == nil {
+		p.c.last_id++
+		k = &lruKeyId{id: strconv.FormatUint(p.c.last_id, 10)}
+		p.ids[key] = k
+	}
+	return k
+}
+
+// lookupId returns the id of key, or false if key is not in the cache.
+func (p *LRU[K, V]) lookupId(key K) (id string, ok bool) {
+	p.c.mu.Lock()
+	defer p.c.mu.Unlock()
+
+	if k := p.ids[key]; k != nil {
+		return k.id, true
+	}
+	return "", false
+}
+
+// evicted drops the id of the key whose entry leaves the cache, the
+// id is kept for the new entry replacing the old one and the loads.
+// REQUIRES: p.c.mu must be held.
+func (p *LRU[K, V]) evicted(id string, value interface{}, reason EvictReason) {
+	if reason == EvictReplaced {
+		return
+	}
+	key := value.(*lruEntry[K, V]).key
+	if k := p.ids[key]; k != nil && k.id == id && k.pending == 0 {
+		delete(p.ids, key)
+	}
+}
+
+// Destroys all existing entries by calling the "deleter"
+// function that was passed to the constructor.
+// REQUIRES: all handles must have been released.
+func (p *LRU[K, V]) Close() error {
+	return p.c.Close()
+}
+
+func (p *LRU[K, V]) Get(key K) (value V, ok bool) {
+	if v, h, ok := p.Lookup(key); ok {
+		h.Close()
+		return v, ok
+	}
+	return
+}
+
+// GetFrom returns the value of key, the missing value is loaded by
+// loader and put to the cache.  Concurrent misses of the same key
+// share the same load, see LRUCache.GetFrom.
+func (p *LRU[K, V]) GetFrom(key K, loader func(key K) (v V, size int, err error)) (value V, err error) {
+	if loader == nil {
+		if v, h, ok := p.Lookup(key); ok {
+			h.Close()
+			return v, nil
+		}
+		return value, fmt.Errorf("cache: %v not found!", key)
+	}
+
+	p.c.mu.Lock()
+	k := p.keyId(key)
+	k.pending++
+	p.c.mu.Unlock()
+
+	defer func() {
+		p.c.mu.Lock()
+		defer p.c.mu.Unlock()
+
+		// drop the id if the load failed
+		if k.pending--; k.pending == 0 && p.c.table[k.id] == nil && p.ids[key] == k {
+			delete(p.ids, key)
+		}
+	}()
+
+	v, err := p.c.GetFrom(k.id, func(string) (interface{}, int, error) {
+		value, size, err := loader(key)
+		return &lruEntry[K, V]{key: key, value: value}, size, err
+	})
+	if err != nil {
+		return
+	}
+	return v.(*lruEntry[K, V]).value, nil
+}
+
+func (p *LRU[K, V]) Value(key K, defaultValue ...V) (value V) {
+	if v, h, ok := p.Lookup(key); ok {
+		h.Close()
+		return v
+	}
+	if len(defaultValue) > 0 {
+		return defaultValue[0]
+	}
+	return
+}
+
+func (p *LRU[K, V]) Set(key K, value V, size int, deleter ...func(key K, value V)) {
+	if len(deleter) > 0 {
+		h := p.Insert(key, value, size, deleter[0])
+		h.Close()
+	} else {
+		h := p.Insert(key, value, size, nil)
+		h.Close()
+	}
+}
+
+// Return a new numeric id.  May be used by multiple clients who are
+// sharing the same cache to partition the key space.
+func (p *LRU[K, V]) NewId() uint64 {
+	return p.c.NewId()
+}
+
+// Insert a mapping from key->value into the cache and assign it
+// the specified size against the total cache capacity.
+//
+// Return a handle that corresponds to the mapping.  The caller
+// must call handle.Close() when the returned mapping is no
+// longer needed.
+//
+// When the inserted entry is no longer needed, the key and
+// value will be passed to "deleter".
+func (p *LRU[K, V]) Insert(key K, value V, size int, deleter func(key K, value V)) (handle *Handle[K, V]) {
+	p.c.mu.Lock()
+	defer p.c.mu.Unlock()
+
+	assert(size > 0)
+	e := &lruEntry[K, V]{key: key, value: value, deleter: deleter}
+	if deleter != nil {
+		return &Handle[K, V]{p.c.insert(p.keyId(key).id, e, size, lruDeleter[K, V], p.c.ttl, 2, true)}
+	}
+	return &Handle[K, V]{p.c.insert(p.keyId(key).id, e, size, nil, p.c.ttl, 2, true)}
+}
+
+// If the cache has no mapping for "key", returns zero value, nil, false.
+//
+// Else return a handle that corresponds to the mapping.  The caller
+// must call handle.Close() when the returned mapping is no
+// longer needed.
+func (p *LRU[K, V]) Lookup(key K) (value V, handle *Handle[K, V], ok bool) {
+	id, ok := p.lookupId(key)
+	if !ok {
+		p.c.stats.miss()
+		return
+	}
+	if _, h, ok := p.c.Lookup_(id); ok {
+		handle = &Handle[K, V]{h}
+		return handle.Value(), handle, true
+	}
+	return
+}
+
+// If the cache has no mapping for "key", returns nil, false.
+//
+// Else return a handle that corresponds to the mapping and erase it.
+// The caller must call handle.Close() when the returned mapping is no
+// longer needed.
+func (p *LRU[K, V]) Take(key K) (handle *Handle[K, V], ok bool) {
+	if id, ok := p.lookupId(key); ok {
+		if h, ok := p.c.Take(id); ok {
+			return &Handle[K, V]{h.(*LRUHandle)}, true
+		}
+	}
+	return nil, false
+}
+
+// If the cache contains entry for key, erase it.  Note that the
+// underlying entry will be kept around until all existing handles
+// to it have been released.
+func (p *LRU[K, V]) Erase(key K) {
+	if id, ok := p.lookupId(key); ok {
+		p.c.Erase(id)
+	}
+}
+
+// SetCapacity will set the capacity of the cache. If the capacity is
+// smaller, and the current cache size exceed that capacity, the cache
+// will be shrank.
+func (p *LRU[K, V]) SetCapacity(capacity int64) {
+	p.c.SetCapacity(capacity)
+}
+
+// Stats returns a few stats on the cache.
+func (p *LRU[K, V]) Stats() (length, size, capacity int64, oldest time.Time) {
+	return p.c.Stats()
+}
+
+// StatsSnapshot returns all the stats of the cache.
+func (p *LRU[K, V]) StatsSnapshot() Stats {
+	return p.c.StatsSnapshot()
+}
+
+// StatsJSON returns stats as a JSON object in a string.
+func (p *LRU[K, V]) StatsJSON() string {
+	if p == nil {
+		return "{}"
+	}
+	return p.c.StatsJSON()
+}
+
+// Length returns how many elements are in the cache
+func (p *LRU[K, V]) Length() int64 {
+	return p.c.Length()
+}
+
+// Size returns the sum of the objects' Size() method.
+func (p *LRU[K, V]) Size() int64 {
+	return p.c.Size()
+}
+
+// Capacity returns the cache maximum capacity.
+func (p *LRU[K, V]) Capacity() int64 {
+	return p.c.Capacity()
+}
+
+// Keys returns all the keys for the cache, ordered from most recently
+// used to last recently used.
+func (p *LRU[K, V]) Keys() []K {
+	p.c.mu.Lock()
+	defer p.c.mu.Unlock()
+
+	keys := make([]K, 0, p.c.list.Len())
+	for e := p.c.list.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(*LRUHandle).value.(*lruEntry[K, V]).key)
+	}
+	return keys
+}
+
+// Destroys all existing entries by calling the "deleter"
+// function that was passed to the constructor.
+func (p *LRU[K, V]) Clear() {
+	p.c.Clear()
+}
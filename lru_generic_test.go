@@ -0,0 +1,218 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestLRU_hitAndMiss(t *testing.T) {
+	var deleted []int
+	c := NewLRU[int, string](tCacheSize)
+	defer c.Close()
+
+	onDeleter := func(key int, value string) {
+		deleted = append(deleted, key)
+	}
+
+	_, ok := c.Get(100)
+	tAssertFalse(t, ok)
+
+	c.Set(100, "101", 1, onDeleter)
+	tAssertEQ(t, "101", c.Value(100))
+	tAssertEQ(t, "null", c.Value(200, "null"))
+
+	c.Set(100, "102", 1, onDeleter)
+	tAssertEQ(t, "102", c.Value(100))
+	tAssertEQ(t, 1, len(deleted))
+	tAssertEQ(t, 100, deleted[0])
+}
+
+func TestLRU_entriesArePinned(t *testing.T) {
+	var deleted []string
+	c := NewLRU[string, int](tCacheSize)
+	defer c.Close()
+
+	onDeleter := func(key string, value int) {
+		deleted = append(deleted, key)
+	}
+
+	h1 := c.Insert("100", 101, 1, onDeleter)
+	c.Erase("100")
+	tAssertEQ(t, 101, h1.Value())
+	tAssertEQ(t, 0, len(deleted))
+
+	h2 := h1.Retain()
+	h1.Close()
+	tAssertEQ(t, 0, len(deleted))
+	h2.Close()
+	tAssertEQ(t, 1, len(deleted))
+}
+
+func TestLRU_evictionPolicy(t *testing.T) {
+	c := NewLRU[int, int](tCacheSize)
+	defer c.Close()
+
+	c.Set(100, 101, 1)
+	c.Set(200, 201, 1)
+
+	for i := 0; i < tCacheSize+100; i++ {
+		c.Set(1000+i, 2000+i, 1)
+		tAssertEQ(t, 2000+i, c.Value(1000+i, -1))
+		tAssertEQ(t, 101, c.Value(100, -1))
+	}
+	tAssertEQ(t, 101, c.Value(100, -1))
+	tAssertEQ(t, -1, c.Value(200, -1))
+	tAssertEQ(t, int64(tCacheSize), c.Length())
+}
+
+func TestLRU_GetFrom(t *testing.T) {
+	c := NewLRU[int, string](tCacheSize)
+	defer c.Close()
+
+	errLoad := errors.New("load failed")
+	loader := func(key int) (string, int, error) {
+		if key < 0 {
+			return "", 0, errLoad
+		}
+		return "v", 1, nil
+	}
+
+	v, err := c.GetFrom(1, loader)
+	tAssertNil(t, err)
+	tAssertEQ(t, "v", v)
+	tAssertTrue(t, c.Length() == 1)
+
+	_, err = c.GetFrom(-1, loader)
+	tAssertTrue(t, err == errLoad)
+
+	_, err = c.GetFrom(2, nil)
+	tAssertNotNil(t, err)
+}
+
+func TestLRU_keys(t *testing.T) {
+	type point struct {
+		X, Y int
+		Name string
+	}
+	c := NewLRU[point, int](tCacheSize)
+	defer c.Close()
+
+	c.Set(point{1, 2, ""}, 12, 1)
+	c.Set(point{1, 2, "a"}, 120, 1)
+	c.Set(point{2, 1, ""}, 21, 1)
+	tAssertEQ(t, 12, c.Value(point{1, 2, ""}))
+	tAssertEQ(t, []point{{1, 2, ""}, {2, 1, ""}, {1, 2, "a"}}, c.Keys())
+
+	_, h, ok := c.Lookup(point{1, 2, "a"})
+	tAssertTrue(t, ok)
+	tAssertEQ(t, point{1, 2, "a"}, h.Key())
+	tAssertEQ(t, 120, h.Value())
+	h.Close()
+
+	h, ok = c.Take(point{2, 1, ""})
+	tAssertTrue(t, ok)
+	tAssertEQ(t, point{2, 1, ""}, h.Key())
+	h.Close()
+	tAssertEQ(t, int64(2), c.Length())
+
+	// the empty string is a valid key
+	s := NewLRU[string, int](tCacheSize)
+	defer s.Close()
+	s.Set("", 1, 1)
+	tAssertEQ(t, 1, s.Value(""))
+	tAssertEQ(t, []string{""}, s.Keys())
+}
+
+func TestLRU_keyIds(t *testing.T) {
+	type T struct{ X int }
+
+	// the distinct pointers are distinct keys
+	c := NewLRU[*T, int](tCacheSize)
+	defer c.Close()
+	a, b := &T{1}, &T{1}
+	c.Set(a, 1, 1)
+	c.Set(b, 2, 1)
+	tAssertEQ(t, int64(2), c.Length())
+	tAssertEQ(t, 1, c.Value(a))
+	tAssertEQ(t, 2, c.Value(b))
+
+	// the equal floats are the same key
+	f := NewLRU[float64, string](tCacheSize)
+	defer f.Close()
+	zero, negZero := 0.0, math.Copysign(0, -1)
+	f.Set(zero, "zero", 1)
+	tAssertEQ(t, "zero", f.Value(negZero))
+	f.Set(negZero, "-zero", 1)
+	tAssertEQ(t, int64(1), f.Length())
+	tAssertEQ(t, "-zero", f.Value(zero))
+
+	// the interface keys are compared with the dynamic types
+	i := NewLRU[any, string](tCacheSize)
+	defer i.Close()
+	i.Set(1, "int", 1)
+	i.Set(int64(1), "int64", 1)
+	i.Set("1", "string", 1)
+	tAssertEQ(t, int64(3), i.Length())
+	tAssertEQ(t, "int", i.Value(1))
+	tAssertEQ(t, "int64", i.Value(int64(1)))
+
+	// the ids are dropped when the entries leave the cache
+	s := NewLRU[int, int](2)
+	defer s.Close()
+	s.Set(1, 1, 1)
+	s.Set(2, 2, 1)
+	s.Set(3, 3, 1)
+	s.Set(3, 30, 1)
+	tAssertEQ(t, 2, len(s.ids))
+	s.Erase(2)
+	tAssertEQ(t, 1, len(s.ids))
+	_, err := s.GetFrom(4, func(key int) (int, int, error) {
+		return 0, 0, errors.New("load failed")
+	})
+	tAssertNotNil(t, err)
+	tAssertEQ(t, 1, len(s.ids))
+	s.Clear()
+	tAssertEQ(t, 0, len(s.ids))
+}
+
+func TestLRU_concurrent(t *testing.T) {
+	c := NewLRU[int, int](8)
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := (g + i) % 16
+				switch i % 4 {
+				case 0:
+					c.Set(key, i, 1)
+				case 1:
+					c.Get(key)
+				case 2:
+					c.Erase(key)
+				case 3:
+					c.GetFrom(key, func(key int) (int, int, error) {
+						return key, 1, nil
+					})
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// every key in the cache has one id, and no id is left over
+	tAssertEQ(t, int(c.Length()), len(c.ids))
+	for _, key := range c.Keys() {
+		_, ok := c.Get(key)
+		tAssertTrue(t, ok, key)
+	}
+}
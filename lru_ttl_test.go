@@ -0,0 +1,96 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLRUCache_ttl(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	c := tNewTCache(tCacheSize, WithClock(clock))
+	defer c.Close()
+
+	h := c.LRUCache.InsertWithTTL("100", 101, 1, time.Minute, c.onDeleter)
+	c.LRUCache.SetWithTTL("200", 201, 1, time.Hour, c.onDeleter)
+	tAssertEQ(t, 101, c.Lookup(100))
+	tAssertEQ(t, 201, c.Lookup(200))
+
+	clock.Advance(time.Minute)
+	tAssertEQ(t, -1, c.Lookup(100))
+	tAssertFalse(t, c.HasKey("100"))
+	tAssertEQ(t, 201, c.Lookup(200))
+
+	// the expired entry is kept until the handle released
+	tAssertEQ(t, 0, len(c.deleted_keys_))
+	h.Close()
+	tAssertEQ(t, 1, len(c.deleted_keys_))
+	tAssertEQ(t, 100, c.deleted_keys_[0])
+}
+
+func TestLRUCache_defaultTTL(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	c := tNewTCache(tCacheSize, WithClock(clock))
+	defer c.Close()
+
+	c.SetDefaultTTL(time.Minute)
+	tAssertEQ(t, time.Minute, c.DefaultTTL())
+
+	for i := 0; i < 10; i++ {
+		c.Insert(i, 1000+i)
+	}
+	c.LRUCache.SetWithTTL("100", 101, 1, 0, c.onDeleter)
+
+	clock.Advance(time.Minute)
+	for i := 0; i < 10; i++ {
+		_, ok := c.Get(strconv.Itoa(i))
+		tAssertFalse(t, ok)
+	}
+	tAssertEQ(t, 10, len(c.deleted_keys_))
+	tAssertEQ(t, 101, c.Lookup(100))
+}
+
+func TestLRUCache_idleTimeout(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	c := tNewTCache(tCacheSize, WithClock(clock))
+	defer c.Close()
+
+	c.SetIdleTimeout(time.Hour)
+	tAssertEQ(t, time.Hour, c.IdleTimeout())
+
+	c.Insert(100, 101)
+	c.Insert(200, 201)
+	tAssertEQ(t, 0, c.RemoveExpired())
+
+	// the lookup refreshes the access time
+	clock.Advance(40 * time.Minute)
+	tAssertEQ(t, 101, c.Lookup(100))
+	clock.Advance(40 * time.Minute)
+	tAssertEQ(t, 101, c.Lookup(100))
+	tAssertEQ(t, -1, c.Lookup(200))
+
+	clock.Advance(time.Hour)
+	tAssertEQ(t, 1, c.RemoveExpired())
+	tAssertEQ(t, int64(0), c.Length())
+}
+
+func TestLRUCache_idleJanitor(t *testing.T) {
+	c := tNewTCache(tCacheSize)
+	defer c.Close()
+
+	c.SetIdleTimeout(10 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		c.Insert(i, 1000+i)
+	}
+
+	// removed by the background janitor, without any access
+	for i := 0; i < 100 && c.Length() > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	tAssertEQ(t, int64(0), c.Length())
+	tAssertEQ(t, 10, len(c.deleted_keys_))
+}
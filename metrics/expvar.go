@@ -0,0 +1,40 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"sync"
+)
+
+// JSONSource is the cache whose stats can be published by expvar, such
+// as *cache.LRUCache, *cache.ShardedLRUCache, *limit.Opener and
+// *worker.Worker.
+type JSONSource interface {
+	StatsJSON() string
+}
+
+var expvarMu sync.Mutex
+
+// PublishExpvar publishes the live stats of the cache under the name
+// in expvar, so /debug/vars shows them.  It returns ErrDuplicateName
+// if the name has been published.
+func PublishExpvar(name string, src JSONSource) error {
+	if name == "" || src == nil {
+		return ErrInvalidName
+	}
+
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	if expvar.Get(name) != nil {
+		return ErrDuplicateName
+	}
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return json.RawMessage(src.StatsJSON())
+	}))
+	return nil
+}
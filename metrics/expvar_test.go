@@ -0,0 +1,89 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/chai2010/cache"
+	"github.com/chai2010/cache/limit"
+	"github.com/chai2010/cache/worker"
+)
+
+// tExpvarRun makes the published names unique across the runs of
+// go test -count, expvar can not unpublish a name.
+var tExpvarRun int32
+
+func tExpvarName(name string) string {
+	return fmt.Sprintf("metrics_test.%d.%s", atomic.LoadInt32(&tExpvarRun), name)
+}
+
+func TestPublishExpvar(t *testing.T) {
+	atomic.AddInt32(&tExpvarRun, 1)
+
+	c1 := cache.NewLRUCache(10)
+	defer c1.Close()
+	c2 := cache.NewShardedLRUCache(100, 4)
+	defer c2.Close()
+	w := worker.NewWorker(10)
+
+	tAssertNil(t, PublishExpvar(tExpvarName("c1"), c1))
+	tAssertNil(t, PublishExpvar(tExpvarName("c2"), c2))
+	tAssertNil(t, PublishExpvar(tExpvarName("worker"), w))
+	tAssert(t, PublishExpvar(tExpvarName("c1"), c2) == ErrDuplicateName)
+	tAssert(t, PublishExpvar("", c2) == ErrInvalidName)
+
+	// live stats
+	c1.Set("a", 1, 3)
+
+	var v struct {
+		Length int64
+		Size   int64
+	}
+	tAssertNil(t, json.Unmarshal([]byte(expvar.Get(tExpvarName("c1")).String()), &v))
+	tAssertEQ(t, int64(1), v.Length)
+	tAssertEQ(t, int64(3), v.Size)
+
+	w.AddTask("task", func() {})
+	tAssertNil(t, json.Unmarshal([]byte(expvar.Get(tExpvarName("worker")).String()), &v))
+	tAssertEQ(t, int64(1), v.Length)
+}
+
+func TestPublishExpvar_closed(t *testing.T) {
+	atomic.AddInt32(&tExpvarRun, 1)
+
+	c := cache.NewLRUCache(10)
+	opener := limit.NewOpener(
+		func(name string) (interface{}, error) { return name, nil },
+		func(f interface{}) error { return nil },
+		10, nil,
+	)
+	tAssertNil(t, PublishExpvar(tExpvarName("closed"), c))
+	tAssertNil(t, PublishExpvar(tExpvarName("opener"), opener))
+
+	c.Set("a", 1, 3)
+	c.Close()
+	opener.Close()
+
+	// the published stats are still readable after closed
+	var v struct {
+		Length  int64
+		Size    int64
+		Inserts uint64
+	}
+	tAssertNil(t, json.Unmarshal([]byte(expvar.Get(tExpvarName("closed")).String()), &v))
+	tAssertEQ(t, int64(0), v.Length)
+	tAssertEQ(t, int64(0), v.Size)
+	tAssertEQ(t, uint64(1), v.Inserts)
+
+	v.Inserts = 1
+	tAssertNil(t, json.Unmarshal([]byte(expvar.Get(tExpvarName("opener")).String()), &v))
+	tAssertEQ(t, int64(0), v.Length)
+	tAssertEQ(t, uint64(0), v.Inserts)
+}
@@ -0,0 +1,148 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package metrics provides the cache metrics exporter support.
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/chai2010/cache"
+)
+
+var (
+	ErrInvalidName   = errors.New("metrics: invalid name!")
+	ErrDuplicateName = errors.New("metrics: duplicate name!")
+)
+
+// Source is the cache whose stats can be exported, such as
+// *cache.LRUCache and *cache.ShardedLRUCache.
+type Source interface {
+	StatsSnapshot() cache.Stats
+}
+
+// Exporter writes the stats of the named caches in the Prometheus
+// text exposition format.
+//
+// See https://prometheus.io/docs/instrumenting/exposition_formats/
+type Exporter struct {
+	mu      sync.Mutex
+	sources map[string]Source
+}
+
+// NewExporter creates a new empty exporter.
+func NewExporter() *Exporter {
+	return &Exporter{
+		sources: make(map[string]Source),
+	}
+}
+
+// Register adds the cache with the name, the name is used as the
+// value of the "cache" label.
+func (p *Exporter) Register(name string, src Source) error {
+	if name == "" || src == nil {
+		return ErrInvalidName
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.sources[name]; ok {
+		return ErrDuplicateName
+	}
+	p.sources[name] = src
+	return nil
+}
+
+// Unregister removes the cache with the name.
+func (p *Exporter) Unregister(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.sources, name)
+}
+
+type metric struct {
+	name  string
+	typ   string
+	help  string
+	value func(s *cache.Stats) interface{}
+}
+
+var metrics = []metric{
+	{"cache_size", "gauge", "Sum of the sizes of the cached entries.",
+		func(s *cache.Stats) interface{} { return s.Size }},
+	{"cache_capacity", "gauge", "Max sum of the sizes of the cached entries.",
+		func(s *cache.Stats) interface{} { return s.Capacity }},
+	{"cache_entries", "gauge", "Number of the cached entries.",
+		func(s *cache.Stats) interface{} { return s.Length }},
+	{"cache_hits_total", "counter", "Number of the lookups which found the entry.",
+		func(s *cache.Stats) interface{} { return s.Hits }},
+	{"cache_misses_total", "counter", "Number of the lookups which not found the entry.",
+		func(s *cache.Stats) interface{} { return s.Misses }},
+	{"cache_evictions_total", "counter", "Number of the entries evicted for the capacity.",
+		func(s *cache.Stats) interface{} { return s.Evictions }},
+}
+
+// WriteTo writes the metrics of all the caches to w.
+func (p *Exporter) WriteTo(w io.Writer) (n int64, err error) {
+	p.mu.Lock()
+	names := make([]string, 0, len(p.sources))
+	sources := make([]Source, 0, len(p.sources))
+	for name := range p.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sources = append(sources, p.sources[name])
+	}
+	p.mu.Unlock()
+
+	stats := make([]cache.Stats, len(sources))
+	for i, src := range sources {
+		stats[i] = src.StatsSnapshot()
+	}
+
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, m := range metrics {
+		fmt.Fprintf(bw, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", m.name, m.typ)
+		for i, name := range names {
+			fmt.Fprintf(bw, "%s{cache=\"%s\"} %v\n", m.name, escapeLabel(name), m.value(&stats[i]))
+		}
+	}
+	err = bw.Flush()
+	return cw.n, err
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (p *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (p *countWriter) Write(data []byte) (n int, err error) {
+	n, err = p.w.Write(data)
+	p.n += int64(n)
+	return
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chai2010/cache"
+)
+
+func TestExporter(t *testing.T) {
+	c1 := cache.NewLRUCache(10)
+	defer c1.Close()
+	c2 := cache.NewShardedLRUCache(100, 4)
+	defer c2.Close()
+
+	c1.Set("a", 1, 3)
+	c1.Get("a")
+	c1.Get("b")
+	c2.Set("a", 1, 1)
+
+	p := NewExporter()
+	tAssertNil(t, p.Register("users", c1))
+	tAssertNil(t, p.Register(`blocks"1"`, c2))
+	tAssert(t, p.Register("users", c2) == ErrDuplicateName)
+	tAssert(t, p.Register("", c2) == ErrInvalidName)
+
+	var buf bytes.Buffer
+	n, err := p.WriteTo(&buf)
+	tAssertNil(t, err)
+	tAssertEQ(t, int64(buf.Len()), n)
+
+	for _, line := range []string{
+		"# TYPE cache_size gauge",
+		"# TYPE cache_hits_total counter",
+		`cache_size{cache="users"} 3`,
+		`cache_capacity{cache="users"} 10`,
+		`cache_entries{cache="users"} 1`,
+		`cache_hits_total{cache="users"} 1`,
+		`cache_misses_total{cache="users"} 1`,
+		`cache_evictions_total{cache="users"} 0`,
+		`cache_capacity{cache="blocks\"1\""} 100`,
+		`cache_entries{cache="blocks\"1\""} 1`,
+	} {
+		tAssert(t, strings.Contains(buf.String(), line+"\n"), line)
+	}
+
+	p.Unregister(`blocks"1"`)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	tAssert(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+	tAssert(t, !strings.Contains(w.Body.String(), "blocks"))
+}
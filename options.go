@@ -0,0 +1,106 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"time"
+)
+
+// Option configures the LRUCache.
+type Option func(o *options)
+
+type options struct {
+	capacity    int64
+	max_entries int64
+	deleter     func(key string, value interface{})
+	clock       Clock
+	on_evict    func(key string, value interface{}, reason EvictReason)
+	ttl         time.Duration
+	idle        time.Duration
+	stats       bool
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		clock: SystemClock,
+		stats: true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithCapacity sets the capacity of the cache, it is the total sum of
+// the size of each item.  The capacity is required.
+func WithCapacity(capacity int64) Option {
+	return func(o *options) {
+		o.capacity = capacity
+	}
+}
+
+// WithMaxEntries sets the max number of the items in the cache, a
+// non-positive value means no limit.
+func WithMaxEntries(n int64) Option {
+	return func(o *options) {
+		if n < 0 {
+			n = 0
+		}
+		o.max_entries = n
+	}
+}
+
+// WithDeleter sets the default deleter, which is used when Insert,
+// Set, PushFront or PushBack is called with a nil deleter.
+func WithDeleter(deleter func(key string, value interface{})) Option {
+	return func(o *options) {
+		o.deleter = deleter
+	}
+}
+
+// WithClock sets the clock used for the access time and expiration.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		if clock != nil {
+			o.clock = clock
+		}
+	}
+}
+
+// WithEvictionListener sets the listener which is called when an entry
+// leaves the cache table, see LRUCache.OnEvict.
+func WithEvictionListener(fn func(key string, value interface{}, reason EvictReason)) Option {
+	return func(o *options) {
+		o.on_evict = fn
+	}
+}
+
+// WithTTL sets the default time to live of the entries.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl < 0 {
+			ttl = 0
+		}
+		o.ttl = ttl
+	}
+}
+
+// WithIdleTimeout sets the max idle time of the entries.
+func WithIdleTimeout(idle time.Duration) Option {
+	return func(o *options) {
+		if idle < 0 {
+			idle = 0
+		}
+		o.idle = idle
+	}
+}
+
+// WithStats enables or disables the stats collection, default is
+// enabled.
+func WithStats(enabled bool) Option {
+	return func(o *options) {
+		o.stats = enabled
+	}
+}
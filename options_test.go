@@ -0,0 +1,114 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNewLRUCacheWithOptions(t *testing.T) {
+	var deleted, evicted []string
+	clock := NewFakeClock(time.Now())
+
+	c := NewLRUCacheWithOptions(
+		WithCapacity(100),
+		WithMaxEntries(3),
+		WithDeleter(func(key string, value interface{}) {
+			deleted = append(deleted, key)
+		}),
+		WithEvictionListener(func(key string, value interface{}, reason EvictReason) {
+			if reason != EvictErased {
+				evicted = append(evicted, key)
+			}
+		}),
+		WithClock(clock),
+		WithTTL(time.Minute),
+	)
+	defer c.Close()
+
+	tAssertEQ(t, int64(100), c.Capacity())
+	tAssertEQ(t, time.Minute, c.DefaultTTL())
+
+	for i := 0; i < 5; i++ {
+		c.Set(strconv.Itoa(i), i, 1)
+	}
+	tAssertEQ(t, int64(3), c.Length())
+	tAssertEQ(t, []string{"0", "1"}, evicted)
+	tAssertEQ(t, []string{"0", "1"}, deleted)
+
+	c.Erase("4")
+	tAssertEQ(t, []string{"0", "1"}, evicted)
+	tAssertEQ(t, []string{"0", "1", "4"}, deleted)
+
+	clock.Advance(time.Minute)
+	_, ok := c.Get("3")
+	tAssertFalse(t, ok)
+	tAssertEQ(t, []string{"0", "1", "3"}, evicted)
+}
+
+func TestNewLRUCacheWithOptions_stats(t *testing.T) {
+	c := NewLRUCacheWithOptions(WithCapacity(2))
+	defer c.Close()
+
+	c.Set("a", 1, 1)
+	c.Set("b", 2, 1)
+	c.Set("c", 3, 1)
+	c.Get("a")
+	c.Get("b")
+
+	stats := c.CacheStats()
+	tAssertEQ(t, uint64(1), stats.Hits)
+	tAssertEQ(t, uint64(1), stats.Misses)
+	tAssertEQ(t, uint64(1), stats.Evictions)
+
+	c2 := NewLRUCacheWithOptions(WithCapacity(2), WithStats(false))
+	defer c2.Close()
+
+	c2.Set("a", 1, 1)
+	c2.Get("a")
+	c2.Get("b")
+	tAssertEQ(t, CacheStats{}, c2.CacheStats())
+}
+
+func TestNewLRUCacheWithOptions_idleTimeout(t *testing.T) {
+	c := NewLRUCacheWithOptions(WithCapacity(10), WithIdleTimeout(time.Hour))
+	defer c.Close()
+
+	tAssertEQ(t, time.Hour, c.IdleTimeout())
+}
+
+func TestNewLRUCacheWithOptions_noCapacity(t *testing.T) {
+	tAssertPanic(t, func() {
+		NewLRUCacheWithOptions(WithTTL(time.Minute))
+	})
+}
+
+func TestLRUCache_SetMaxEntries(t *testing.T) {
+	c := tNewTCache(100)
+	defer c.Close()
+
+	tAssertEQ(t, int64(0), c.MaxEntries())
+	for i := 0; i < 10; i++ {
+		c.Insert(i, 1000+i)
+	}
+
+	// capacity is not exceeded, but entries are
+	c.SetMaxEntries(4)
+	tAssertEQ(t, int64(4), c.MaxEntries())
+	tAssertEQ(t, int64(4), c.Length())
+	tAssertEQ(t, []string{"9", "8", "7", "6"}, c.Keys())
+	tAssertEQ(t, 6, len(c.deleted_keys_))
+	tAssertEQ(t, uint64(6), c.CacheStats().Evictions)
+
+	c.Insert(10, 1010)
+	tAssertEQ(t, int64(4), c.Length())
+	tAssertEQ(t, int64(4), c.StatsSnapshot().MaxEntries)
+
+	c.SetMaxEntries(0)
+	c.Insert(11, 1011)
+	tAssertEQ(t, int64(5), c.Length())
+}
@@ -0,0 +1,918 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"container/list"
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// evictionPolicy orders the entries of a policyCache.
+// REQUIRES: all the methods are called with the cache lock held.
+type evictionPolicy interface {
+	// add a new entry to the policy.
+	add(h *LRUHandle)
+
+	// hit records an access of the entry.
+	hit(h *LRUHandle)
+
+	// remove the entry from the policy.
+	remove(h *LRUHandle)
+
+	// replace puts the new entry h to the place of old, which has the
+	// same key, so the history of the key is kept.  The size of h may
+	// differ from old.
+	replace(old, h *LRUHandle)
+
+	// front returns the entry which will be evicted last, or nil.
+	front() *LRUHandle
+
+	// oldest returns the entry which will be evicted next if no more
+	// entries are accessed, or nil.  It must not change the policy, so
+	// the peeks like Back and Stats do not change the eviction order.
+	oldest() *LRUHandle
+
+	// appendKeys appends the keys ordered from front to back.
+	appendKeys(keys []string) []string
+
+	// clear removes all the entries.
+	clear()
+}
+
+// ghostPolicy is implemented by the policies which remember the keys
+// of the evicted entries, evict is called instead of remove for the
+// entries evicted for the capacity.
+type ghostPolicy interface {
+	evict(h *LRUHandle)
+}
+
+// victimPolicy is implemented by the policies which reorder the
+// entries while choosing the victim, victim is called by evict instead
+// of oldest.
+type victimPolicy interface {
+	victim() *LRUHandle
+}
+
+// preparePolicy is implemented by the policies which need the key of
+// the new entry before the victims are chosen.
+type preparePolicy interface {
+	prepare(key string, size int64)
+}
+
+// missPolicy is implemented by the policies which record the keys
+// missed by Lookup.
+type missPolicy interface {
+	miss(key string)
+}
+
+// sharedHitPolicy is implemented by the policies whose hits can be
+// recorded concurrently, sharedHit is called with the read lock held
+// instead of hit, and must not change the order of the entries.
+type sharedHitPolicy interface {
+	sharedHit(h *LRUHandle)
+}
+
+// resizePolicy is implemented by the policies which track the size of
+// the entries, resized is called after the size of h changed.
+type resizePolicy interface {
+	resized(h *LRUHandle)
+}
+
+// policyCache is the common part of the caches other than LRUCache,
+// the eviction order is decided by the policy.
+//
+// The entries are refcounted just like the LRUCache, but the ttl and
+// the idle timeout options are not supported.
+type policyCache struct {
+	mu sync.RWMutex
+
+	// table of *LRUHandle objects
+	table  map[string]*LRUHandle
+	policy evictionPolicy
+
+	// Our current size and the size limit.
+	size     int64
+	capacity int64
+
+	// Max number of the entries, 0 means no limit.
+	max_entries int64
+
+	// default deleter for the entries without deleter
+	deleter func(key string, value interface{})
+
+	// called when an entry leaves the table
+	on_evict func(key string, value interface{}, reason EvictReason)
+
+	// nil if the stats collection is disabled
+	stats *cacheCounters
+
+	// source of the current time
+	clock Clock
+
+	// for next id
+	last_id uint64
+}
+
+func newPolicyCache(capacity int64, policy evictionPolicy, opts ...Option) *policyCache {
+	o := newOptions(append([]Option{WithCapacity(capacity)}, opts...)...)
+	assert(o.capacity > 0)
+
+	p := &policyCache{
+		table:       make(map[string]*LRUHandle),
+		policy:      policy,
+		capacity:    o.capacity,
+		max_entries: o.max_entries,
+		deleter:     o.deleter,
+		on_evict:    o.on_evict,
+		stats:       newCacheCounters(o.stats),
+		clock:       o.clock,
+	}
+	runtime.SetFinalizer(p, (*policyCache).Close)
+	return p
+}
+
+func (p *policyCache) Get(key string) (value interface{}, ok bool) {
+	if v, h, ok := p.Lookup(key); ok {
+		h.Close()
+		return v, ok
+	}
+	return
+}
+
+func (p *policyCache) Value(key string, defaultValue ...interface{}) interface{} {
+	if v, h, ok := p.Lookup(key); ok {
+		h.Close()
+		return v
+	}
+	if len(defaultValue) > 0 {
+		return defaultValue[0]
+	} else {
+		return nil
+	}
+}
+
+func (p *policyCache) Set(key string, value interface{}, size int, deleter ...func(key string, value interface{})) {
+	if len(deleter) > 0 {
+		h := p.Insert(key, value, size, deleter[0])
+		h.Close()
+	} else {
+		h := p.Insert(key, value, size, nil)
+		h.Close()
+	}
+}
+
+// Return a new numeric id.
+func (p *policyCache) NewId() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.last_id++
+	return p.last_id
+}
+
+// Insert a mapping from key->value into the cache and assign it
+// the specified size against the total cache capacity.
+//
+// Return a handle that corresponds to the mapping.  The caller
+// must call handle.Close() when the returned mapping is no
+// longer needed.
+func (p *policyCache) Insert(key string, value interface{}, size int, deleter func(key string, value interface{})) (handle io.Closer) {
+	handle = p.Insert_(key, value, size, deleter)
+	return
+}
+
+// Insert_ same as Insert, but return *LRUHandle.
+func (p *policyCache) Insert_(key string, value interface{}, size int, deleter func(key string, value interface{})) (handle *LRUHandle) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.insert(key, value, size, deleter)
+}
+
+// TryInsert same as Insert, but returns ErrInvalidKey or ErrInvalidSize
+// instead of panic if the key is empty or the size is not positive.
+func (p *policyCache) TryInsert(key string, value interface{}, size int, deleter func(key string, value interface{})) (handle io.Closer, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := checkEntry(key, size); err != nil {
+		return nil, err
+	}
+	return p.insert(key, value, size, deleter), nil
+}
+
+// insert a new entry, the entries are evicted before the new entry is
+// added to the policy, so the new entry is never the victim.  But the
+// entry which replaces an old one takes the place of the old one, and
+// the set is treated as a hit.
+// REQUIRES: p.mu must be held.
+func (p *policyCache) insert(key string, value interface{}, size int, deleter func(key string, value interface{})) (handle *LRUHandle) {
+	assert(key != "" && size > 0)
+	if deleter == nil {
+		deleter = p.deleter
+	}
+
+	now := p.clock.Now()
+	h := &LRUHandle{
+		c:            p,
+		key:          key,
+		value:        value,
+		size:         int64(size),
+		deleter:      deleter,
+		time_created: now,
+		refs:         2, // One from the cache, one for the returned handle
+	}
+	h.time_accessed.Store(now)
+
+	if old := p.table[key]; old != nil {
+		p.policy.replace(old, h)
+		p.policy.hit(h)
+		p.table[key] = h
+		p.evicted(old, EvictReplaced)
+		p.unref(old)
+		p.stats.replace()
+
+		p.size += h.size
+		p.stats.insert()
+		p.checkCapacity()
+		return h
+	}
+
+	if x, ok := p.policy.(preparePolicy); ok {
+		x.prepare(key, h.size)
+	}
+	p.size += h.size
+	for p.overflow(1) && len(p.table) > 0 {
+		p.evict()
+	}
+
+	p.table[key] = h
+	p.policy.add(h)
+	p.stats.insert()
+	return h
+}
+
+// If the cache has no mapping for "key", returns nil, nil, false.
+//
+// Else return a handle that corresponds to the mapping.  The caller
+// must call handle.Close() when the returned mapping is no
+// longer needed.
+func (p *policyCache) Lookup(key string) (value interface{}, handle io.Closer, ok bool) {
+	// warning: (*LRUHandle)(nil) != (io.Closer)(nil)
+	if v, h, ok := p.Lookup_(key); ok {
+		return v, h, ok
+	}
+	return
+}
+
+// Lookup_ same as Lookup, but return *LRUHandle.
+func (p *policyCache) Lookup_(key string) (value interface{}, handle *LRUHandle, ok bool) {
+	if x, ok := p.policy.(sharedHitPolicy); ok {
+		return p.lookupShared(key, x)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h := p.table[key]
+	if h == nil {
+		if x, ok := p.policy.(missPolicy); ok {
+			x.miss(key)
+		}
+		p.stats.miss()
+		return nil, nil, false
+	}
+
+	p.policy.hit(h)
+	h.time_accessed.Store(p.clock.Now())
+	p.addref(h)
+	p.stats.hit()
+	return h.Value(), h, true
+}
+
+// lookupShared same as Lookup_, but only the read lock is held, so the
+// concurrent hits do not block each other.
+func (p *policyCache) lookupShared(key string, policy sharedHitPolicy) (value interface{}, handle *LRUHandle, ok bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	h := p.table[key]
+	if h == nil {
+		p.stats.miss()
+		return nil, nil, false
+	}
+
+	policy.sharedHit(h)
+	h.time_accessed.Store(p.clock.Now())
+	p.addref(h)
+	p.stats.hit()
+	return h.Value(), h, true
+}
+
+func (p *policyCache) HasKey(key string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.table[key] != nil
+}
+
+// If the cache has no mapping for "key", returns nil, false.
+//
+// Else return a handle that corresponds to the mapping and erase it.
+// The caller must call handle.Close() when the returned mapping is no
+// longer needed.
+func (p *policyCache) Take(key string) (handle io.Closer, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h := p.table[key]
+	if h == nil {
+		return nil, false
+	}
+
+	p.policy.remove(h)
+	delete(p.table, key)
+	p.evicted(h, EvictErased)
+	return h, true
+}
+
+// If the cache contains entry for key, erase it.  Note that the
+// underlying entry will be kept around until all existing handles
+// to it have been released.
+func (p *policyCache) Erase(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h := p.table[key]
+	if h == nil {
+		return
+	}
+
+	p.policy.remove(h)
+	delete(p.table, key)
+	p.evicted(h, EvictErased)
+	p.unref(h)
+	p.stats.erase()
+}
+
+// Resize updates the size of the entry of key in place, see
+// LRUHandle.SetSize.  It returns ErrNotFound if the cache has no
+// mapping for key.
+func (p *policyCache) Resize(key string, size int) error {
+	if size <= 0 {
+		return ErrInvalidSize
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h := p.table[key]
+	if h == nil {
+		return ErrNotFound
+	}
+	p.resize(h, int64(size))
+	return nil
+}
+
+// SetCapacity will set the capacity of the cache. If the capacity is
+// smaller, and the current cache size exceed that capacity, the cache
+// will be shrank.
+func (p *policyCache) SetCapacity(capacity int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	assert(capacity > 0)
+	p.capacity = capacity
+	p.checkCapacity()
+}
+
+// SetMaxEntries will set the max number of the entries, a non-positive
+// n means no limit.
+func (p *policyCache) SetMaxEntries(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if n < 0 {
+		n = 0
+	}
+	p.max_entries = n
+	p.checkCapacity()
+}
+
+// MaxEntries returns the max number of the entries, 0 means no limit.
+func (p *policyCache) MaxEntries() int64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.max_entries
+}
+
+// OnEvict sets the listener which is called when an entry leaves the
+// cache table, see LRUCache.OnEvict.
+func (p *policyCache) OnEvict(fn func(key string, value interface{}, reason EvictReason)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.on_evict = fn
+}
+
+// Stats returns a few stats on the cache, oldest is the access time
+// of the entry which will be evicted next.
+func (p *policyCache) Stats() (length, size, capacity int64, oldest time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if h := p.policy.oldest(); h != nil {
+		oldest = h.TimeAccessed()
+	}
+	return int64(len(p.table)), p.size, p.capacity, oldest
+}
+
+// StatsSnapshot returns the stats on the cache, NewestAccess and
+// OldestAccess are the access time of the front and back entries.
+func (p *policyCache) StatsSnapshot() (s Stats) {
+	p.mu.Lock()
+	s.Length = int64(len(p.table))
+	s.Size = p.size
+	s.Capacity = p.capacity
+	s.MaxEntries = p.max_entries
+	if h := p.policy.front(); h != nil {
+		s.NewestAccess = h.TimeAccessed()
+	}
+	if h := p.policy.oldest(); h != nil {
+		s.OldestAccess = h.TimeAccessed()
+	}
+	p.mu.Unlock()
+
+	s.CacheStats = p.stats.snapshot()
+	return
+}
+
+// StatsJSON returns stats as a JSON object in a string.
+func (p *policyCache) StatsJSON() string {
+	if p == nil {
+		return "{}"
+	}
+	return marshalStats(p.StatsSnapshot())
+}
+
+// CacheStats returns the hit and eviction counters.
+func (p *policyCache) CacheStats() CacheStats {
+	return p.stats.snapshot()
+}
+
+// ResetStats resets the hit and eviction counters.
+func (p *policyCache) ResetStats() {
+	p.stats.reset()
+}
+
+// Length returns how many elements are in the cache
+func (p *policyCache) Length() int64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return int64(len(p.table))
+}
+
+// Size returns the sum of the objects' Size() method.
+func (p *policyCache) Size() int64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.size
+}
+
+// Capacity returns the cache maximum capacity.
+func (p *policyCache) Capacity() int64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.capacity
+}
+
+// Keys returns all the keys for the cache, ordered from the entry
+// which will be evicted last to the entry which will be evicted next.
+func (p *policyCache) Keys() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.policy.appendKeys(make([]string, 0, len(p.table)))
+}
+
+// Front returns the entry which will be evicted last, or nil if the
+// cache is empty.  The caller must call h.Close() after use.
+func (p *policyCache) Front() (h *LRUHandle) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if h = p.policy.front(); h != nil {
+		p.addref(h)
+	}
+	return
+}
+
+// Back returns the entry which will be evicted next, or nil if the
+// cache is empty.  The caller must call h.Close() after use.
+func (p *policyCache) Back() (h *LRUHandle) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if h = p.policy.oldest(); h != nil {
+		p.addref(h)
+	}
+	return
+}
+
+func (p *policyCache) FrontKey() (key string) {
+	if h := p.Front(); h != nil {
+		key = h.Key()
+		h.Close()
+		return key
+	}
+	return ""
+}
+
+func (p *policyCache) BackKey() (key string) {
+	if h := p.Back(); h != nil {
+		key = h.Key()
+		h.Close()
+		return key
+	}
+	return ""
+}
+
+func (p *policyCache) FrontValue(defaultValue ...interface{}) (value interface{}) {
+	if h := p.Front(); h != nil {
+		value = h.Value()
+		h.Close()
+		return
+	}
+	if len(defaultValue) > 0 {
+		return defaultValue[0]
+	} else {
+		return nil
+	}
+}
+
+func (p *policyCache) BackValue(defaultValue ...interface{}) (value interface{}) {
+	if h := p.Back(); h != nil {
+		value = h.Value()
+		h.Close()
+		return
+	}
+	if len(defaultValue) > 0 {
+		return defaultValue[0]
+	} else {
+		return nil
+	}
+}
+
+// PopFront erases the front entry and returns it, the caller must call
+// h.Close() after use.
+func (p *policyCache) PopFront() (h *LRUHandle) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if h = p.policy.front(); h != nil {
+		p.policy.remove(h)
+		delete(p.table, h.key)
+		p.evicted(h, EvictErased)
+	}
+	return
+}
+
+// PopBack erases the back entry and returns it, the caller must call
+// h.Close() after use.
+func (p *policyCache) PopBack() (h *LRUHandle) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if h = p.policy.oldest(); h != nil {
+		p.policy.remove(h)
+		delete(p.table, h.key)
+		p.evicted(h, EvictErased)
+	}
+	return
+}
+
+func (p *policyCache) RemoveFront() {
+	if h := p.PopFront(); h != nil {
+		h.Close()
+	}
+}
+
+func (p *policyCache) RemoveBack() {
+	if h := p.PopBack(); h != nil {
+		h.Close()
+	}
+}
+
+// Destroys all existing entries by calling the "deleter"
+// function that was passed to the constructor.
+func (p *policyCache) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, h := range p.table {
+		p.evicted(h, EvictCleared)
+		p.unref(h)
+	}
+
+	p.policy.clear()
+	p.table = make(map[string]*LRUHandle)
+	p.size = 0
+}
+
+// Destroys all existing entries by calling the "deleter"
+// function that was passed to the constructor.
+// REQUIRES: all handles must have been released.
+func (p *policyCache) Close() error {
+	runtime.SetFinalizer(p, nil)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, h := range p.table {
+		refs := atomic.LoadUint32(&h.refs)
+		assert(refs == 1, "h.refs = ", refs)
+		p.evicted(h, EvictClosed)
+		p.unref(h)
+	}
+
+	p.policy.clear()
+	p.table = nil
+	p.size = 0
+	return nil
+}
+
+func (p *policyCache) lock() {
+	p.mu.Lock()
+}
+
+func (p *policyCache) unlock() {
+	p.mu.Unlock()
+}
+
+// addref is atomic, because it may be called with only the read lock
+// held by lookupShared.
+func (p *policyCache) addref(h *LRUHandle) {
+	atomic.AddUint32(&h.refs, 1)
+}
+
+// release drops the reference of a handle, the lock is taken only for
+// the last reference.  So closing the handles of the hits does not
+// block the concurrent hits of lookupShared.
+func (p *policyCache) release(h *LRUHandle) {
+	for {
+		refs := atomic.LoadUint32(&h.refs)
+		assert(refs > 0)
+		if refs == 1 {
+			break
+		}
+		if atomic.CompareAndSwapUint32(&h.refs, refs, refs-1) {
+			return
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unref(h)
+}
+
+// unref is atomic, because the other references may be dropped by
+// release without the lock.
+// REQUIRES: p.mu must be held.
+func (p *policyCache) unref(h *LRUHandle) {
+	assert(atomic.LoadUint32(&h.refs) > 0)
+	if atomic.AddUint32(&h.refs, ^uint32(0)) == 0 {
+		p.size -= h.size
+		if h.deleter != nil {
+			h.deleter(h.key, h.value)
+			p.stats.delete()
+		}
+	}
+}
+
+// resize updates the size of h, and shrinks the cache if necessary.
+// REQUIRES: p.mu must be held.
+func (p *policyCache) resize(h *LRUHandle, size int64) {
+	assert(atomic.LoadUint32(&h.refs) > 0)
+	p.size += size - h.size
+	atomic.StoreInt64(&h.size, size)
+	if x, ok := p.policy.(resizePolicy); ok && p.table[h.key] == h {
+		x.resized(h)
+	}
+	p.checkCapacity()
+}
+
+// overflow reports whether the cache exceeds the limits after adding
+// n more entries.
+func (p *policyCache) overflow(n int) bool {
+	if p.max_entries > 0 && int64(len(p.table)+n) > p.max_entries {
+		return true
+	}
+	return p.size > p.capacity
+}
+
+// evict removes the victim of the policy.
+// REQUIRES: p.mu must be held.
+func (p *policyCache) evict() {
+	var h *LRUHandle
+	if x, ok := p.policy.(victimPolicy); ok {
+		h = x.victim()
+	} else {
+		h = p.policy.oldest()
+	}
+	if x, ok := p.policy.(ghostPolicy); ok {
+		x.evict(h)
+	} else {
+		p.policy.remove(h)
+	}
+	delete(p.table, h.key)
+	p.stats.evict()
+	p.evicted(h, EvictCapacity)
+	p.unref(h)
+}
+
+func (p *policyCache) checkCapacity() {
+	// must keep at least one entry, same as LRUCache
+	for p.overflow(0) && len(p.table) > 1 {
+		p.evict()
+	}
+}
+
+// evicted notifies the eviction listener.
+// REQUIRES: p.mu must be held.
+func (p *policyCache) evicted(h *LRUHandle, reason EvictReason) {
+	if p.on_evict != nil {
+		p.on_evict(h.key, h.value, reason)
+	}
+}
+
+// lruSegment is a LRU list of the entries used by the policies, the
+// front is the most recently used.
+type lruSegment struct {
+	list *list.List // of *LRUHandle
+	size int64
+}
+
+// segmentLink is the LRUHandle.link of the entries of lruSegment.
+type segmentLink struct {
+	segment *lruSegment
+	element *list.Element
+	size    int64
+}
+
+func newLRUSegment() *lruSegment {
+	return &lruSegment{list: list.New()}
+}
+
+// segmentOf returns the segment which holds h.
+func segmentOf(h *LRUHandle) *lruSegment {
+	return h.link.(*segmentLink).segment
+}
+
+func (s *lruSegment) Len() int {
+	return s.list.Len()
+}
+
+func (s *lruSegment) pushFront(h *LRUHandle) {
+	h.link = &segmentLink{segment: s, element: s.list.PushFront(h), size: h.size}
+	s.size += h.size
+}
+
+func (s *lruSegment) moveToFront(h *LRUHandle) {
+	s.list.MoveToFront(h.link.(*segmentLink).element)
+}
+
+func (s *lruSegment) remove(h *LRUHandle) {
+	link := h.link.(*segmentLink)
+	s.list.Remove(link.element)
+	s.size -= link.size
+	h.link = nil
+}
+
+// replace puts h to the place of old.
+func (s *lruSegment) replace(old, h *LRUHandle) {
+	link := old.link.(*segmentLink)
+	link.element.Value = h
+	h.link, old.link = link, nil
+	s.resized(h)
+}
+
+// resized updates the size of the segment after the size of h changed.
+func (s *lruSegment) resized(h *LRUHandle) {
+	link := h.link.(*segmentLink)
+	s.size += h.size - link.size
+	link.size = h.size
+}
+
+func (s *lruSegment) front() *LRUHandle {
+	if e := s.list.Front(); e != nil {
+		return e.Value.(*LRUHandle)
+	}
+	return nil
+}
+
+func (s *lruSegment) back() *LRUHandle {
+	if e := s.list.Back(); e != nil {
+		return e.Value.(*LRUHandle)
+	}
+	return nil
+}
+
+func (s *lruSegment) appendKeys(keys []string) []string {
+	for e := s.list.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(*LRUHandle).key)
+	}
+	return keys
+}
+
+func (s *lruSegment) clear() {
+	s.list.Init()
+	s.size = 0
+}
+
+// promote moves h from the probation segment to the front of the
+// protected segment, the back entries of the protected segment are
+// demoted to the front of the probation segment if it exceeds max.
+func promote(h *LRUHandle, probation, protected *lruSegment, max int64) {
+	probation.remove(h)
+	protected.pushFront(h)
+	demote(probation, protected, max)
+}
+
+// demote moves the back entries of the protected segment to the front
+// of the probation segment, until it does not exceed max.
+func demote(probation, protected *lruSegment, max int64) {
+	for protected.size > max && protected.Len() > 1 {
+		h := protected.back()
+		protected.remove(h)
+		probation.pushFront(h)
+	}
+}
+
+// ghostQueue is a FIFO of the keys of the evicted entries used by the
+// policies, the front is the most recently evicted.  The ghosts hold
+// no values, only the keys and sizes.
+type ghostQueue struct {
+	list  *list.List // of *ghostEntry
+	table map[string]*list.Element
+	size  int64
+}
+
+type ghostEntry struct {
+	key  string
+	size int64
+}
+
+func newGhostQueue() *ghostQueue {
+	return &ghostQueue{
+		list:  list.New(),
+		table: make(map[string]*list.Element),
+	}
+}
+
+func (q *ghostQueue) Len() int {
+	return q.list.Len()
+}
+
+// lookup returns the size of the ghost of key.
+func (q *ghostQueue) lookup(key string) (size int64, ok bool) {
+	if e := q.table[key]; e != nil {
+		return e.Value.(*ghostEntry).size, true
+	}
+	return 0, false
+}
+
+// push puts the ghost of h to the front.
+func (q *ghostQueue) push(h *LRUHandle, size int64) {
+	q.remove(h.key)
+	q.table[h.key] = q.list.PushFront(&ghostEntry{key: h.key, size: size})
+	q.size += size
+}
+
+func (q *ghostQueue) remove(key string) {
+	if e := q.table[key]; e != nil {
+		q.removeElement(e)
+	}
+}
+
+// removeBack removes the least recently evicted ghost.
+func (q *ghostQueue) removeBack() {
+	if e := q.list.Back(); e != nil {
+		q.removeElement(e)
+	}
+}
+
+func (q *ghostQueue) removeElement(e *list.Element) {
+	g := e.Value.(*ghostEntry)
+	q.list.Remove(e)
+	delete(q.table, g.key)
+	q.size -= g.size
+}
+
+func (q *ghostQueue) clear() {
+	q.list.Init()
+	q.table = make(map[string]*list.Element)
+	q.size = 0
+}
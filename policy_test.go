@@ -0,0 +1,187 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// tPolicyCaches are the constructors of all the caches built on the
+// policyCache.
+var tPolicyCaches = []struct {
+	name string
+	new  func(capacity int64, opts ...Option) *policyCache
+}{
+	{"LFU", func(n int64, opts ...Option) *policyCache { return NewLFUCache(n, opts...).policyCache }},
+	{"ARC", func(n int64, opts ...Option) *policyCache { return NewARCCache(n, opts...).policyCache }},
+	{"TinyLFU", func(n int64, opts ...Option) *policyCache { return NewTinyLFUCache(n, opts...).policyCache }},
+	{"SLRU", func(n int64, opts ...Option) *policyCache { return NewSLRUCache(n, 0, opts...).policyCache }},
+	{"2Q", func(n int64, opts ...Option) *policyCache { return NewTwoQueueCache(n, 0, 0, opts...).policyCache }},
+	{"CLOCK", func(n int64, opts ...Option) *policyCache { return NewClockCache(n, opts...).policyCache }},
+	{"SIEVE", func(n int64, opts ...Option) *policyCache { return NewSieveCache(n, opts...).policyCache }},
+	{"S3-FIFO", func(n int64, opts ...Option) *policyCache { return NewS3FIFOCache(n, opts...).policyCache }},
+}
+
+func TestPolicyCache_handle(t *testing.T) {
+	for _, tt := range tPolicyCaches {
+		var deleted []string
+		c := tt.new(1, WithDeleter(func(key string, value interface{}) {
+			deleted = append(deleted, key)
+		}))
+
+		h := c.Insert("a", 1, 1, nil)
+		c.Set("b", 2, 1)
+
+		// evicted, but pinned by the handle, and its size is still
+		// counted until released
+		tAssertFalse(t, c.HasKey("a"), tt.name)
+		tAssertEQ(t, 0, len(deleted), tt.name)
+		tAssertEQ(t, 1, h.(*LRUHandle).Value(), tt.name)
+		tAssertEQ(t, int64(2), c.Size(), tt.name)
+
+		h2 := h.(*LRUHandle).Retain()
+		h.Close()
+		tAssertEQ(t, 0, len(deleted), tt.name)
+		h2.Close()
+		tAssertEQ(t, []string{"a"}, deleted, tt.name)
+		tAssertEQ(t, int64(1), c.Size(), tt.name)
+
+		// all handles must have been released
+		_, h, ok := c.Lookup("b")
+		tAssertTrue(t, ok, tt.name)
+		tAssertPanic(t, func() { c.Close() }, tt.name)
+		h.Close()
+
+		c.Clear()
+		tAssertEQ(t, []string{"a", "b"}, deleted, tt.name)
+		tAssertEQ(t, int64(0), c.Length(), tt.name)
+		tAssertEQ(t, int64(0), c.Size(), tt.name)
+		c.Close()
+	}
+}
+
+func TestPolicyCache_peek(t *testing.T) {
+	for _, tt := range tPolicyCaches {
+		var deleted [2][]string
+		var caches [2]*policyCache
+		for i := range caches {
+			i := i
+			caches[i] = tt.new(10, WithDeleter(func(key string, value interface{}) {
+				deleted[i] = append(deleted[i], key)
+			}))
+			defer caches[i].Close()
+		}
+
+		for _, c := range caches {
+			for i := 0; i < 10; i++ {
+				c.Set(strconv.Itoa(i), i, 1)
+			}
+			for i := 0; i < 10; i += 2 {
+				c.Get(strconv.Itoa(i))
+			}
+		}
+
+		// the peeks do not change the eviction order
+		back := caches[0].BackKey()
+		for i := 0; i < 50; i++ {
+			caches[0].Stats()
+			caches[0].StatsSnapshot()
+			tAssertEQ(t, back, caches[0].BackKey(), tt.name)
+		}
+		tAssertEQ(t, caches[1].Keys(), caches[0].Keys(), tt.name)
+
+		for _, c := range caches {
+			for i := 10; i < 20; i++ {
+				c.Set(strconv.Itoa(i), i, 1)
+			}
+		}
+		tAssertEQ(t, deleted[1], deleted[0], tt.name)
+		tAssertEQ(t, caches[1].Keys(), caches[0].Keys(), tt.name)
+	}
+}
+
+func TestPolicyCache_sharedHit(t *testing.T) {
+	for _, tt := range tPolicyCaches {
+		c := tt.new(10)
+		if _, ok := c.policy.(sharedHitPolicy); !ok {
+			c.Close()
+			continue
+		}
+		c.Set("a", 1, 1)
+
+		// the hits and the handles closed take only the read lock
+		c.mu.RLock()
+		done := make(chan bool)
+		go func() {
+			_, ok := c.Get("a")
+			done <- ok
+		}()
+		select {
+		case ok := <-done:
+			tAssertTrue(t, ok, tt.name)
+		case <-time.After(time.Second):
+			t.Fatalf("%s: Get blocked by the read lock", tt.name)
+		}
+		c.mu.RUnlock()
+		c.Close()
+	}
+}
+
+func TestPolicyCache_scan(t *testing.T) {
+	hot := make([]string, 10)
+	for i := range hot {
+		hot[i] = "hot" + strconv.Itoa(i)
+	}
+
+	for _, tt := range []struct {
+		name string
+		new  func(capacity int64, opts ...Option) *policyCache
+		warm func(c *policyCache) // nil means the hot keys are set and then hit
+	}{
+		{"LFU", func(n int64, opts ...Option) *policyCache { return NewLFUCache(n, opts...).policyCache }, nil},
+		{"ARC", func(n int64, opts ...Option) *policyCache { return NewARCCache(n, opts...).policyCache }, nil},
+		{"TinyLFU", func(n int64, opts ...Option) *policyCache { return NewTinyLFUCache(n, opts...).policyCache }, nil},
+		{"SLRU", func(n int64, opts ...Option) *policyCache { return NewSLRUCache(n, 0, opts...).policyCache }, nil},
+		{"S3-FIFO", func(n int64, opts ...Option) *policyCache { return NewS3FIFOCache(n, opts...).policyCache }, nil},
+		{"2Q", func(n int64, opts ...Option) *policyCache { return NewTwoQueueCache(n, 0, 0, opts...).policyCache }, func(c *policyCache) {
+			// the hot keys are seen again after evicted from A1in
+			for _, key := range hot {
+				c.Set(key, 0, 1)
+			}
+			for i := 0; i < 95; i++ {
+				c.Set("warm"+strconv.Itoa(i), i, 1)
+			}
+			for _, key := range hot {
+				c.Set(key, 0, 1)
+			}
+		}},
+	} {
+		c := tt.new(100)
+		if tt.warm != nil {
+			tt.warm(c)
+		} else {
+			for _, key := range hot {
+				c.Set(key, 0, 1)
+			}
+			for k := 0; k < 3; k++ {
+				for _, key := range hot {
+					c.Get(key)
+				}
+			}
+		}
+
+		// one-off scan does not flush the hot set
+		for i := 0; i < 1000; i++ {
+			c.Set("scan"+strconv.Itoa(i), i, 1)
+		}
+		for _, key := range hot {
+			tAssertTrue(t, c.HasKey(key), tt.name, key)
+		}
+		tAssertEQ(t, int64(100), c.Length(), tt.name)
+		c.Close()
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"testing"
+)
+
+func TestLRUCache_Resize(t *testing.T) {
+	c := tNewTCache(10)
+	defer c.Close()
+
+	c.Insert(1, 101, 2)
+	c.Insert(2, 201, 2)
+	c.Insert(3, 301, 2)
+	tAssertEQ(t, int64(6), c.Size())
+
+	tAssertNil(t, c.Resize("2", 4))
+	tAssertEQ(t, int64(8), c.Size())
+	tAssertEQ(t, []string{"3", "2", "1"}, c.Keys())
+	tAssertEQ(t, 0, len(c.deleted_keys_))
+
+	tAssertTrue(t, c.Resize("4", 1) == ErrNotFound)
+	tAssertTrue(t, c.Resize("1", 0) == ErrInvalidSize)
+
+	// grow beyond the capacity evicts the oldest
+	tAssertNil(t, c.Resize("3", 5))
+	tAssertEQ(t, int64(9), c.Size())
+	tAssertEQ(t, []string{"3", "2"}, c.Keys())
+	tAssertEQ(t, []int{1}, c.deleted_keys_)
+}
+
+func TestLRUHandle_SetSize(t *testing.T) {
+	c := tNewTCache(10)
+	defer c.Close()
+
+	c.Insert(1, 101, 2)
+	h := c.LRUCache.Insert_("2", 201, 2, c.onDeleter)
+	tAssertEQ(t, 2, h.Size())
+
+	tAssertNil(t, h.SetSize(3))
+	tAssertEQ(t, 3, h.Size())
+	tAssertEQ(t, int64(5), c.Size())
+	tAssertTrue(t, h.SetSize(-1) == ErrInvalidSize)
+
+	// the erased entry is still counted until released
+	c.Erase(2)
+	tAssertNil(t, h.SetSize(4))
+	tAssertEQ(t, int64(6), c.Size())
+	h.Close()
+	tAssertEQ(t, int64(2), c.Size())
+	tAssertEQ(t, []int{2}, c.deleted_keys_)
+}
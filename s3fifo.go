@@ -0,0 +1,197 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+// assert match interface
+var _ Cache = (*S3FIFOCache)(nil)
+
+const (
+	s3fifoSmallRatio = 0.1 // ratio of the small queue to the capacity
+	s3fifoMaxFreq    = 3   // max access frequency of the entries
+)
+
+// S3FIFOCache is a S3-FIFO cache implementation.
+//
+// The new entries are inserted into a small FIFO queue (10% of the
+// capacity), the entries accessed at least once while in the small
+// queue are moved to the main FIFO queue, and the others are evicted
+// and remembered in a ghost queue.  The entries whose keys are in the
+// ghost queue are inserted into the main queue directly.  The entries
+// of the main queue are reinserted with a decreased frequency if they
+// have been accessed, otherwise evicted.
+//
+// A hit only increases the frequency of the entry (up to 3), which is
+// done atomically with the read lock.  The ghosts hold no values, and
+// are limited by the size of the main queue.
+//
+// See https://dl.acm.org/doi/10.1145/3600006.3613147
+type S3FIFOCache struct {
+	*policyCache
+}
+
+// NewS3FIFOCache creates a new empty S3-FIFO cache with the given
+// capacity.  The WithTTL and WithIdleTimeout options are not supported.
+func NewS3FIFOCache(capacity int64, opts ...Option) *S3FIFOCache {
+	policy := &s3fifoPolicy{
+		small: newFIFOQueue(),
+		main:  newFIFOQueue(),
+		ghost: newGhostQueue(),
+	}
+	p := newPolicyCache(capacity, policy, opts...)
+	policy.capacity = &p.capacity
+	return &S3FIFOCache{p}
+}
+
+type s3fifoPolicy struct {
+	capacity *int64
+
+	small *fifoQueue
+	main  *fifoQueue
+	ghost *ghostQueue
+
+	// the new entry hits the ghost queue, set by prepare
+	pending bool
+}
+
+func (p *s3fifoPolicy) smallMax() int64 {
+	if n := int64(float64(*p.capacity) * s3fifoSmallRatio); n > 0 {
+		return n
+	}
+	return 1
+}
+
+func (p *s3fifoPolicy) prepare(key string, size int64) {
+	_, p.pending = p.ghost.lookup(key)
+	p.ghost.remove(key)
+}
+
+// add puts the new entry to the main queue if its key is a ghost,
+// else to the small queue.
+func (p *s3fifoPolicy) add(h *LRUHandle) {
+	if p.pending {
+		p.main.push(h)
+	} else {
+		p.small.push(h)
+	}
+	p.pending = false
+}
+
+func (p *s3fifoPolicy) hit(h *LRUHandle) {
+	p.sharedHit(h)
+}
+
+func (p *s3fifoPolicy) sharedHit(h *LRUHandle) {
+	fifoTouch(h, s3fifoMaxFreq)
+}
+
+func (p *s3fifoPolicy) remove(h *LRUHandle) {
+	fifoLinkOf(h).queue.remove(h)
+}
+
+// evict removes the entry, and remembers its key in the ghost queue
+// if it is evicted from the small queue.
+func (p *s3fifoPolicy) evict(h *LRUHandle) {
+	link := fifoLinkOf(h)
+	link.queue.remove(h)
+
+	if link.queue == p.small {
+		p.ghost.push(h, link.size)
+		max := *p.capacity - p.smallMax()
+		for p.ghost.size > max && p.ghost.Len() > 0 {
+			p.ghost.removeBack()
+		}
+	}
+}
+
+func (p *s3fifoPolicy) replace(old, h *LRUHandle) {
+	fifoLinkOf(old).queue.replace(old, h)
+}
+
+func (p *s3fifoPolicy) resized(h *LRUHandle) {
+	fifoLinkOf(h).queue.resized(h)
+}
+
+func (p *s3fifoPolicy) front() *LRUHandle {
+	if h := p.main.front(); h != nil {
+		return h
+	}
+	return p.small.front()
+}
+
+// oldest returns the entry which victim will choose, without moving
+// the entries or changing the frequencies.
+func (p *s3fifoPolicy) oldest() *LRUHandle {
+	// the accessed entries of the small queue would be moved to the
+	// front of the main queue with a zero frequency
+	var moved *LRUHandle // the first moved entry
+	size, n := p.small.size, p.main.Len()
+	for e := p.small.list.Back(); e != nil && (size >= p.smallMax() || n == 0); e = e.Prev() {
+		h := e.Value.(*LRUHandle)
+		if fifoFreq(h) == 0 {
+			return h
+		}
+		if moved == nil {
+			moved = h
+		}
+		size -= fifoLinkOf(h).size
+		n++
+	}
+
+	// every round of the main queue decreases the frequencies by one,
+	// so the first entry with the lowest frequency is the victim
+	var victim *LRUHandle
+	for e := p.main.list.Back(); e != nil; e = e.Prev() {
+		if h := e.Value.(*LRUHandle); victim == nil || fifoFreq(h) < fifoFreq(victim) {
+			victim = h
+		}
+	}
+	if moved != nil && (victim == nil || fifoFreq(victim) > 0) {
+		return moved
+	}
+	return victim
+}
+
+// victim returns the victim of the small queue if it exceeds its ratio
+// of the capacity, else the victim of the main queue.  The accessed
+// entries on the way are moved to (or reinserted into) the main queue.
+func (p *s3fifoPolicy) victim() *LRUHandle {
+	for {
+		if p.small.Len() > 0 && (p.small.size >= p.smallMax() || p.main.Len() == 0) {
+			h := p.small.back()
+			if fifoFreq(h) == 0 {
+				return h
+			}
+			p.small.remove(h)
+			p.main.push(h)
+			continue
+		}
+
+		h := p.main.back()
+		if h == nil {
+			return nil
+		}
+		freq := fifoFreq(h)
+		if freq == 0 {
+			return h
+		}
+		p.main.remove(h)
+		p.main.push(h)
+		fifoSetFreq(h, freq-1)
+	}
+}
+
+// appendKeys appends the keys of the main queue and then the small
+// queue, both from the newest to the oldest.
+func (p *s3fifoPolicy) appendKeys(keys []string) []string {
+	keys = p.main.appendKeys(keys)
+	return p.small.appendKeys(keys)
+}
+
+func (p *s3fifoPolicy) clear() {
+	p.small.clear()
+	p.main.clear()
+	p.ghost.clear()
+	p.pending = false
+}
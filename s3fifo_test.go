@@ -0,0 +1,78 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestS3FIFOCache(t *testing.T) {
+	var deleted []string
+	c := NewS3FIFOCache(10, WithDeleter(func(key string, value interface{}) {
+		deleted = append(deleted, key)
+	}))
+	defer c.Close()
+
+	s3 := c.policy.(*s3fifoPolicy)
+	for i := 0; i < 10; i++ {
+		c.Set("k"+strconv.Itoa(i), i, 1)
+	}
+
+	// not accessed, evicted from the small queue to the ghost queue
+	c.Set("x", 0, 1)
+	tAssertEQ(t, []string{"k0"}, deleted)
+	tAssertEQ(t, 1, s3.ghost.Len())
+
+	// accessed, moved to the main queue
+	c.Get("k1")
+	c.Set("y", 0, 1)
+	tAssertEQ(t, []string{"k0", "k2"}, deleted)
+	tAssertTrue(t, fifoLinkOf(c.table["k1"]).queue == s3.main)
+
+	// the ghost goes to the main queue
+	c.Set("k0", 0, 1)
+	tAssertTrue(t, fifoLinkOf(c.table["k0"]).queue == s3.main)
+	tAssertEQ(t, []string{"k0", "k2", "k3"}, deleted)
+	tAssertEQ(t, []string{"k0", "k1", "y", "x", "k9", "k8", "k7", "k6", "k5", "k4"}, c.Keys())
+
+	// the ghosts call no deleters
+	c.Clear()
+	tAssertEQ(t, 13, len(deleted))
+	tAssertEQ(t, 0, s3.ghost.Len())
+}
+
+func TestS3FIFOCache_main(t *testing.T) {
+	var deleted []string
+	c := NewS3FIFOCache(20, WithDeleter(func(key string, value interface{}) {
+		deleted = append(deleted, key)
+	}))
+	defer c.Close()
+
+	c.Set("a", 1, 9)
+	c.Set("b", 2, 9)
+	c.Get("a")
+	c.Get("b")
+	c.Set("c", 3, 1)
+	c.Set("d", 4, 1)
+	c.Set("e", 5, 1)
+	tAssertEQ(t, []string{"c"}, deleted)
+	tAssertEQ(t, []string{"b", "a", "e", "d"}, c.Keys())
+
+	// the small queue does not exceed its ratio, the accessed entry
+	// of the main queue is reinserted
+	c.Erase("d")
+	c.Get("a")
+	tAssertNil(t, c.Resize("b", 11))
+	tAssertEQ(t, []string{"c", "d", "b"}, deleted)
+	tAssertEQ(t, []string{"a", "e"}, c.Keys())
+	tAssertEQ(t, uint32(0), fifoFreq(c.table["a"]))
+
+	// the frequency is limited
+	for i := 0; i < 10; i++ {
+		c.Get("a")
+	}
+	tAssertEQ(t, uint32(s3fifoMaxFreq), fifoFreq(c.table["a"]))
+}
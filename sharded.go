@@ -0,0 +1,333 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"hash/fnv"
+	"io"
+	"sync"
+	"time"
+)
+
+// assert match interface
+var _ Cache = (*ShardedLRUCache)(nil)
+
+// DefaultShards is the number of shards used by NewShardedLRUCache
+// when shards is not positive.
+const DefaultShards = 16
+
+// ShardedLRUCache is a LRU cache which hashes the keys to several
+// independent LRUCache shards, so the operations on the different
+// shards do not contend for the same lock.
+//
+// See https://github.com/google/leveldb/blob/master/util/cache.cc
+type ShardedLRUCache struct {
+	shards []*LRUCache
+
+	mu      sync.Mutex
+	last_id uint64
+}
+
+// NewShardedLRUCache creates a new empty cache with the given capacity,
+// the capacity (and the WithMaxEntries limit) is split across shards.
+// The number of shards is reduced to the capacity (and the limit), so
+// every shard gets a positive part.
+func NewShardedLRUCache(capacity int64, shards int, opts ...Option) *ShardedLRUCache {
+	assert(capacity > 0)
+	if shards <= 0 {
+		shards = DefaultShards
+	}
+
+	o := newOptions(opts...)
+	if int64(shards) > capacity {
+		shards = int(capacity)
+	}
+	if o.max_entries > 0 && int64(shards) > o.max_entries {
+		shards = int(o.max_entries)
+	}
+
+	p := &ShardedLRUCache{
+		shards: make([]*LRUCache, shards),
+	}
+	for i := range p.shards {
+		shardOpts := append(opts[:len(opts):len(opts)], WithCapacity(shardPart(capacity, shards, i)))
+		if o.max_entries > 0 {
+			shardOpts = append(shardOpts, WithMaxEntries(shardPart(o.max_entries, shards, i)))
+		}
+		p.shards[i] = NewLRUCacheWithOptions(shardOpts...)
+	}
+	return p
+}
+
+// shardPart returns the part of total for the i-th of n shards, the
+// first total%n shards get one more than the others.
+func shardPart(total int64, n, i int) int64 {
+	part := total / int64(n)
+	if int64(i) < total%int64(n) {
+		part++
+	}
+	return part
+}
+
+func (p *ShardedLRUCache) shard(key string) *LRUCache {
+	h := fnv.New32a()
+	io.WriteString(h, key)
+	return p.shards[h.Sum32()%uint32(len(p.shards))]
+}
+
+// Shards returns the number of the shards.
+func (p *ShardedLRUCache) Shards() int {
+	return len(p.shards)
+}
+
+// Destroys all existing entries by calling the "deleter"
+// function that was passed to the constructor.
+// REQUIRES: all handles must have been released.
+func (p *ShardedLRUCache) Close() error {
+	for _, s := range p.shards {
+		s.Close()
+	}
+	return nil
+}
+
+// Return a new numeric id.  May be used by multiple clients who are
+// sharing the same cache to partition the key space.
+func (p *ShardedLRUCache) NewId() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.last_id++
+	return p.last_id
+}
+
+func (p *ShardedLRUCache) Get(key string) (value interface{}, ok bool) {
+	return p.shard(key).Get(key)
+}
+
+func (p *ShardedLRUCache) GetFrom(key string, getter func(key string) (v interface{}, size int, err error)) (value interface{}, err error) {
+	return p.shard(key).GetFrom(key, getter)
+}
+
+func (p *ShardedLRUCache) GetFromContext(ctx context.Context, key string, loader func(ctx context.Context, key string) (v interface{}, size int, err error)) (value interface{}, err error) {
+	return p.shard(key).GetFromContext(ctx, key, loader)
+}
+
+func (p *ShardedLRUCache) Value(key string, defaultValue ...interface{}) interface{} {
+	return p.shard(key).Value(key, defaultValue...)
+}
+
+func (p *ShardedLRUCache) Set(key string, value interface{}, size int, deleter ...func(key string, value interface{})) {
+	p.shard(key).Set(key, value, size, deleter...)
+}
+
+// SetWithTTL same as Set, but the entry expires after ttl.
+func (p *ShardedLRUCache) SetWithTTL(key string, value interface{}, size int, ttl time.Duration, deleter ...func(key string, value interface{})) {
+	p.shard(key).SetWithTTL(key, value, size, ttl, deleter...)
+}
+
+// Insert a mapping from key->value into the cache and assign it
+// the specified size against the total cache capacity.
+//
+// Return a handle that corresponds to the mapping.  The caller
+// must call handle.Close() when the returned mapping is no
+// longer needed.
+func (p *ShardedLRUCache) Insert(key string, value interface{}, size int, deleter func(key string, value interface{})) (handle io.Closer) {
+	return p.shard(key).Insert(key, value, size, deleter)
+}
+
+// Insert_ same as Insert, but return *LRUHandle.
+func (p *ShardedLRUCache) Insert_(key string, value interface{}, size int, deleter func(key string, value interface{})) (handle *LRUHandle) {
+	return p.shard(key).Insert_(key, value, size, deleter)
+}
+
+// TryInsert same as Insert, but returns error instead of panic.
+func (p *ShardedLRUCache) TryInsert(key string, value interface{}, size int, deleter func(key string, value interface{})) (handle io.Closer, err error) {
+	return p.shard(key).TryInsert(key, value, size, deleter)
+}
+
+// InsertWithTTL same as Insert, but the entry expires after ttl.
+func (p *ShardedLRUCache) InsertWithTTL(key string, value interface{}, size int, ttl time.Duration, deleter func(key string, value interface{})) (handle io.Closer) {
+	return p.shard(key).InsertWithTTL(key, value, size, ttl, deleter)
+}
+
+// If the cache has no mapping for "key", returns nil, nil, false.
+//
+// Else return a handle that corresponds to the mapping.  The caller
+// must call handle.Close() when the returned mapping is no
+// longer needed.
+func (p *ShardedLRUCache) Lookup(key string) (value interface{}, handle io.Closer, ok bool) {
+	return p.shard(key).Lookup(key)
+}
+
+// Lookup_ same as Lookup, but return *LRUHandle.
+func (p *ShardedLRUCache) Lookup_(key string) (value interface{}, handle *LRUHandle, ok bool) {
+	return p.shard(key).Lookup_(key)
+}
+
+// Take returns the handle of key and erase it.
+func (p *ShardedLRUCache) Take(key string) (handle io.Closer, ok bool) {
+	return p.shard(key).Take(key)
+}
+
+// If the cache contains entry for key, erase it.  Note that the
+// underlying entry will be kept around until all existing handles
+// to it have been released.
+func (p *ShardedLRUCache) Erase(key string) {
+	p.shard(key).Erase(key)
+}
+
+func (p *ShardedLRUCache) HasKey(key string) bool {
+	return p.shard(key).HasKey(key)
+}
+
+// Resize updates the size of the entry of key in place.
+func (p *ShardedLRUCache) Resize(key string, size int) error {
+	return p.shard(key).Resize(key, size)
+}
+
+// SetCapacity will set the capacity of the cache, it is split across
+// the shards.  Every shard keeps a capacity of at least 1, so the
+// total is more than capacity if capacity is less than Shards().
+func (p *ShardedLRUCache) SetCapacity(capacity int64) {
+	assert(capacity > 0)
+	for i, s := range p.shards {
+		if part := shardPart(capacity, len(p.shards), i); part > 0 {
+			s.SetCapacity(part)
+		} else {
+			s.SetCapacity(1)
+		}
+	}
+}
+
+// SetMaxEntries will set the max number of the entries, it is split
+// across the shards.  A non-positive n means no limit.  Every shard
+// keeps a limit of at least 1, so the total is more than n if n is
+// less than Shards().
+func (p *ShardedLRUCache) SetMaxEntries(n int64) {
+	if n <= 0 {
+		for _, s := range p.shards {
+			s.SetMaxEntries(0)
+		}
+		return
+	}
+	for i, s := range p.shards {
+		if part := shardPart(n, len(p.shards), i); part > 0 {
+			s.SetMaxEntries(part)
+		} else {
+			s.SetMaxEntries(1)
+		}
+	}
+}
+
+// MaxEntries returns the max number of the entries, 0 means no limit.
+func (p *ShardedLRUCache) MaxEntries() (n int64) {
+	for _, s := range p.shards {
+		n += s.MaxEntries()
+	}
+	return
+}
+
+// Destroys all existing entries by calling the "deleter"
+// function that was passed to the constructor.
+func (p *ShardedLRUCache) Clear() {
+	for _, s := range p.shards {
+		s.Clear()
+	}
+}
+
+// Stats returns a few stats on the cache, aggregated across shards.
+func (p *ShardedLRUCache) Stats() (length, size, capacity int64, oldest time.Time) {
+	for _, s := range p.shards {
+		l, sz, c, o := s.Stats()
+		length += l
+		size += sz
+		capacity += c
+		if !o.IsZero() && (oldest.IsZero() || o.Before(oldest)) {
+			oldest = o
+		}
+	}
+	return
+}
+
+// StatsSnapshot returns all the stats of the cache, aggregated across
+// shards, with the stats of each shard in Shards.
+func (p *ShardedLRUCache) StatsSnapshot() (stats Stats) {
+	stats.Shards = make([]Stats, len(p.shards))
+	for i, s := range p.shards {
+		v := s.StatsSnapshot()
+		stats.Shards[i] = v
+		stats.Length += v.Length
+		stats.Size += v.Size
+		stats.Capacity += v.Capacity
+		stats.MaxEntries += v.MaxEntries
+		stats.CacheStats.add(v.CacheStats)
+		if v.NewestAccess.After(stats.NewestAccess) {
+			stats.NewestAccess = v.NewestAccess
+		}
+		if !v.OldestAccess.IsZero() && (stats.OldestAccess.IsZero() || v.OldestAccess.Before(stats.OldestAccess)) {
+			stats.OldestAccess = v.OldestAccess
+		}
+	}
+	return
+}
+
+// StatsJSON returns stats as a JSON object in a string.
+func (p *ShardedLRUCache) StatsJSON() string {
+	if p == nil {
+		return "{}"
+	}
+	return marshalStats(p.StatsSnapshot())
+}
+
+// CacheStats returns the counters of the cache, aggregated across
+// shards.
+func (p *ShardedLRUCache) CacheStats() (stats CacheStats) {
+	for _, s := range p.shards {
+		stats.add(s.CacheStats())
+	}
+	return
+}
+
+// ResetStats resets all the counters of the cache to zero.
+func (p *ShardedLRUCache) ResetStats() {
+	for _, s := range p.shards {
+		s.ResetStats()
+	}
+}
+
+// Length returns how many elements are in the cache
+func (p *ShardedLRUCache) Length() (n int64) {
+	for _, s := range p.shards {
+		n += s.Length()
+	}
+	return
+}
+
+// Size returns the sum of the objects' Size() method.
+func (p *ShardedLRUCache) Size() (n int64) {
+	for _, s := range p.shards {
+		n += s.Size()
+	}
+	return
+}
+
+// Capacity returns the cache maximum capacity.
+func (p *ShardedLRUCache) Capacity() (n int64) {
+	for _, s := range p.shards {
+		n += s.Capacity()
+	}
+	return
+}
+
+// Keys returns all the keys for the cache, the keys of each shard are
+// ordered from most recently used to last recently used.
+func (p *ShardedLRUCache) Keys() []string {
+	var keys []string
+	for _, s := range p.shards {
+		keys = append(keys, s.Keys()...)
+	}
+	return keys
+}
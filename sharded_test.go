@@ -0,0 +1,113 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestShardedLRUCache(t *testing.T) {
+	var deleted []string
+	c := NewShardedLRUCache(tCacheSize, 4)
+	defer c.Close()
+
+	tAssertEQ(t, 4, c.Shards())
+	tAssertEQ(t, int64(tCacheSize), c.Capacity())
+
+	onDeleter := func(key string, value interface{}) {
+		deleted = append(deleted, key)
+	}
+
+	var keys []string
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		keys = append(keys, key)
+		c.Set(key, i, 1, onDeleter)
+	}
+	for i := 0; i < 100; i++ {
+		tAssertEQ(t, i, c.Value(strconv.Itoa(i)))
+	}
+
+	tAssertEQ(t, int64(100), c.Length())
+	tAssertEQ(t, int64(100), c.Size())
+
+	got := c.Keys()
+	sort.Strings(got)
+	sort.Strings(keys)
+	tAssertEQ(t, keys, got)
+
+	_, h, ok := c.Lookup("1")
+	tAssertTrue(t, ok)
+	c.Erase("1")
+	tAssertFalse(t, c.HasKey("1"))
+	tAssertEQ(t, 0, len(deleted))
+	h.Close()
+	tAssertEQ(t, 1, len(deleted))
+
+	l, s, capacity, _ := c.Stats()
+	tAssertEQ(t, int64(99), l)
+	tAssertEQ(t, int64(99), s)
+	tAssertEQ(t, int64(tCacheSize), capacity)
+	tAssertEQ(t, uint64(101), c.CacheStats().Hits)
+}
+
+func TestShardedLRUCache_capacity(t *testing.T) {
+	c := NewShardedLRUCache(40, 4, WithMaxEntries(20))
+	defer c.Close()
+
+	for i := 0; i < 1000; i++ {
+		c.Set(strconv.Itoa(i), i, 1)
+	}
+	tAssertTrue(t, c.Length() <= 20, c.Length())
+	tAssertEQ(t, int64(40), c.Capacity())
+	tAssertEQ(t, int64(20), c.MaxEntries())
+
+	// the capacity is split exactly
+	c2 := NewShardedLRUCache(10, 16)
+	defer c2.Close()
+	tAssertEQ(t, 10, c2.Shards())
+	tAssertEQ(t, int64(10), c2.Capacity())
+	for i := 0; i < 1000; i++ {
+		c2.Set(strconv.Itoa(i), i, 1)
+	}
+	tAssertTrue(t, c2.Length() <= 10, c2.Length())
+
+	c3 := NewShardedLRUCache(103, 4, WithMaxEntries(2))
+	defer c3.Close()
+	tAssertEQ(t, 2, c3.Shards())
+	tAssertEQ(t, int64(103), c3.Capacity())
+	tAssertEQ(t, int64(2), c3.MaxEntries())
+
+	c.SetCapacity(43)
+	tAssertEQ(t, int64(43), c.Capacity())
+	c.SetMaxEntries(7)
+	tAssertEQ(t, int64(7), c.MaxEntries())
+	c.SetMaxEntries(0)
+	tAssertEQ(t, int64(0), c.MaxEntries())
+}
+
+func TestShardedLRUCache_concurrent(t *testing.T) {
+	c := NewShardedLRUCache(tCacheSize, 0)
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := strconv.Itoa(i*1000 + j)
+				c.Set(key, j, 1)
+				c.Get(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	tAssertTrue(t, c.Length() <= c.Capacity())
+}
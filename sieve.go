@@ -0,0 +1,128 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+// assert match interface
+var _ Cache = (*SieveCache)(nil)
+
+// SieveCache is a SIEVE cache implementation.
+//
+// The entries are kept in a FIFO queue, a hit only marks the entry as
+// visited, which is done atomically with the read lock.  To choose a
+// victim, the hand moves from the oldest entry toward the newest one,
+// clears the visited marks, and stops at the first entry not visited.
+// Unlike CLOCK, the new entries are always inserted at the head, and
+// the hand keeps its position after an eviction.
+//
+// See https://www.usenix.org/conference/nsdi24/presentation/zhang-yazhuo
+type SieveCache struct {
+	*policyCache
+}
+
+// NewSieveCache creates a new empty SIEVE cache with the given capacity.
+// The WithTTL and WithIdleTimeout options are not supported.
+func NewSieveCache(capacity int64, opts ...Option) *SieveCache {
+	return &SieveCache{newPolicyCache(capacity, &sievePolicy{queue: newFIFOQueue()}, opts...)}
+}
+
+type sievePolicy struct {
+	queue *fifoQueue
+	hand  *LRUHandle // nil means the oldest entry
+}
+
+func (p *sievePolicy) add(h *LRUHandle) {
+	p.queue.push(h)
+}
+
+func (p *sievePolicy) hit(h *LRUHandle) {
+	p.sharedHit(h)
+}
+
+func (p *sievePolicy) sharedHit(h *LRUHandle) {
+	fifoTouch(h, 1)
+}
+
+// remove moves the hand toward the newest if it points to h.
+func (p *sievePolicy) remove(h *LRUHandle) {
+	if p.hand == h {
+		p.hand = p.newer(h)
+	}
+	p.queue.remove(h)
+}
+
+func (p *sievePolicy) replace(old, h *LRUHandle) {
+	p.queue.replace(old, h)
+	if p.hand == old {
+		p.hand = h
+	}
+}
+
+func (p *sievePolicy) resized(h *LRUHandle) {
+	p.queue.resized(h)
+}
+
+// newer returns the entry inserted just after h, or nil.
+func (p *sievePolicy) newer(h *LRUHandle) *LRUHandle {
+	if e := fifoLinkOf(h).element.Prev(); e != nil {
+		return e.Value.(*LRUHandle)
+	}
+	return nil
+}
+
+func (p *sievePolicy) front() *LRUHandle {
+	return p.queue.front()
+}
+
+// oldest returns the first entry not visited from the hand toward the
+// newest, wrapping around to the oldest, or the entry at the hand if
+// all the entries are visited.  Unlike victim, the hand and the marks
+// are not changed.
+func (p *sievePolicy) oldest() *LRUHandle {
+	start := p.hand
+	if start == nil {
+		if start = p.queue.back(); start == nil {
+			return nil
+		}
+	}
+	for h := start; ; {
+		if fifoFreq(h) == 0 {
+			return h
+		}
+		if h = p.newer(h); h == nil {
+			h = p.queue.back()
+		}
+		if h == start {
+			return start
+		}
+	}
+}
+
+// victim moves the hand to the first entry not visited, the visited
+// marks are cleared on the way.
+func (p *sievePolicy) victim() *LRUHandle {
+	if p.queue.Len() == 0 {
+		return nil
+	}
+	for {
+		if p.hand == nil {
+			p.hand = p.queue.back()
+		}
+		if fifoFreq(p.hand) == 0 {
+			return p.hand
+		}
+		fifoSetFreq(p.hand, 0)
+		p.hand = p.newer(p.hand)
+	}
+}
+
+// appendKeys appends the keys from the newest to the oldest.
+func (p *sievePolicy) appendKeys(keys []string) []string {
+	return p.queue.appendKeys(keys)
+}
+
+func (p *sievePolicy) clear() {
+	p.queue.clear()
+	p.hand = nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSieveCache(t *testing.T) {
+	var deleted []string
+	c := NewSieveCache(3, WithDeleter(func(key string, value interface{}) {
+		deleted = append(deleted, key)
+	}))
+	defer c.Close()
+
+	c.Set("a", 1, 1)
+	c.Set("b", 2, 1)
+	c.Set("c", 3, 1)
+	tAssertEQ(t, []string{"c", "b", "a"}, c.Keys())
+
+	// the visited entry is kept in place
+	c.Get("a")
+	c.Set("d", 4, 1)
+	tAssertEQ(t, []string{"b"}, deleted)
+	tAssertEQ(t, []string{"d", "c", "a"}, c.Keys())
+
+	// the hand keeps its position
+	c.Set("e", 5, 1)
+	c.Set("f", 6, 1)
+	tAssertEQ(t, []string{"b", "c", "d"}, deleted)
+	tAssertEQ(t, []string{"f", "e", "a"}, c.Keys())
+	tAssertEQ(t, "f", c.FrontKey())
+	tAssertEQ(t, "e", c.BackKey())
+
+	// the peeks do not move the hand or clear the marks
+	c.Get("e")
+	tAssertEQ(t, "f", c.BackKey())
+	tAssertEQ(t, uint32(1), fifoFreq(c.table["e"]))
+	tAssertTrue(t, c.policy.(*sievePolicy).hand == c.table["e"])
+
+	// the hand wraps around to the oldest
+	c.Get("f")
+	c.Set("g", 7, 1)
+	tAssertEQ(t, []string{"b", "c", "d", "a"}, deleted)
+	c.Get("g")
+	c.Set("h", 8, 1)
+	tAssertEQ(t, []string{"b", "c", "d", "a", "e"}, deleted)
+	tAssertEQ(t, []string{"h", "g", "f"}, c.Keys())
+}
+
+func TestSieveCache_weighted(t *testing.T) {
+	c := NewSieveCache(10)
+	defer c.Close()
+
+	c.Set("a", 1, 4)
+	c.Set("b", 2, 4)
+	c.Get("a")
+	c.Set("c", 3, 4)
+	tAssertEQ(t, []string{"c", "a"}, c.Keys())
+
+	tAssertNil(t, c.Resize("c", 6))
+	tAssertEQ(t, int64(10), c.Size())
+	tAssertEQ(t, int64(10), c.policy.(*sievePolicy).queue.size)
+}
+
+func TestSieveCache_concurrentHits(t *testing.T) {
+	c := NewSieveCache(100)
+	defer c.Close()
+
+	for i := 0; i < 100; i++ {
+		c.Set(strconv.Itoa(i), i, 1)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := strconv.Itoa((g*7 + i) % 200)
+				if _, h, ok := c.Lookup(key); ok {
+					h.Close()
+				} else if g%2 == 0 {
+					c.Set(key, i, 1)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	tAssertEQ(t, int64(100), c.Length())
+}
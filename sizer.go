@@ -0,0 +1,163 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"io"
+	"reflect"
+	"unsafe"
+)
+
+// Sizer is implemented by the values which know their own size, it is
+// used by SetAuto and InsertAuto instead of the estimated size.
+type Sizer interface {
+	Size() int
+}
+
+// EstimateSize returns the size of v.  If v implements Sizer, it
+// returns v.Size(), else it estimates the memory held by v with
+// reflection: the strings, slices, maps, structs, pointers and
+// interfaces are followed deeply, and the memory referenced more than
+// once (including cycles) is counted only once.
+//
+// The result is always positive, so it can be used as the size of a
+// cache entry.
+func EstimateSize(v interface{}) int {
+	if s, ok := v.(Sizer); ok {
+		if n := s.Size(); n > 0 {
+			return n
+		}
+		return 1
+	}
+	if v == nil {
+		return 1
+	}
+
+	rv := reflect.ValueOf(v)
+	n := int64(rv.Type().Size()) + estimateIndirect(rv, make(map[uintptr]bool))
+	if n <= 0 {
+		return 1
+	}
+	return int(n)
+}
+
+// estimateIndirect returns the size of the memory referenced by v,
+// excluding the size of v itself.
+func estimateIndirect(v reflect.Value, seen map[uintptr]bool) (n int64) {
+	switch v.Kind() {
+	case reflect.String:
+		str := v.String()
+		if len(str) == 0 {
+			return 0
+		}
+		ptr := uintptr(unsafe.Pointer(unsafe.StringData(str)))
+		if seen[ptr] {
+			return 0
+		}
+		seen[ptr] = true
+		return int64(len(str))
+
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return 0
+		}
+		seen[v.Pointer()] = true
+		return int64(v.Type().Elem().Size()) + estimateIndirect(v.Elem(), seen)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		e := v.Elem()
+		return int64(e.Type().Size()) + estimateIndirect(e, seen)
+
+	case reflect.Slice:
+		if v.IsNil() || seen[v.Pointer()] {
+			return 0
+		}
+		seen[v.Pointer()] = true
+		n = int64(v.Cap()) * int64(v.Type().Elem().Size())
+		if !hasIndirect(v.Type().Elem()) {
+			return n
+		}
+		for i := 0; i < v.Len(); i++ {
+			n += estimateIndirect(v.Index(i), seen)
+		}
+		return n
+
+	case reflect.Array:
+		if !hasIndirect(v.Type().Elem()) {
+			return 0
+		}
+		for i := 0; i < v.Len(); i++ {
+			n += estimateIndirect(v.Index(i), seen)
+		}
+		return n
+
+	case reflect.Map:
+		if v.IsNil() || seen[v.Pointer()] {
+			return 0
+		}
+		seen[v.Pointer()] = true
+		entrySize := int64(v.Type().Key().Size() + v.Type().Elem().Size())
+		if !hasIndirect(v.Type().Key()) && !hasIndirect(v.Type().Elem()) {
+			return int64(v.Len()) * entrySize
+		}
+		for it := v.MapRange(); it.Next(); {
+			n += entrySize
+			n += estimateIndirect(it.Key(), seen)
+			n += estimateIndirect(it.Value(), seen)
+		}
+		return n
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			n += estimateIndirect(v.Field(i), seen)
+		}
+		return n
+	}
+
+	// bool, numbers, chan, func and unsafe.Pointer
+	return 0
+}
+
+// hasIndirect reports whether the values of t may have indirect memory
+// counted by estimateIndirect, so the elements of the slices, arrays
+// and maps of the other types are not examined one by one.
+func hasIndirect(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	case reflect.Array:
+		return t.Len() > 0 && hasIndirect(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasIndirect(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetAuto same as Set, but the size is computed by EstimateSize.
+func (p *LRUCache) SetAuto(key string, value interface{}, deleter ...func(key string, value interface{})) {
+	p.Set(key, value, EstimateSize(value), deleter...)
+}
+
+// InsertAuto same as Insert, but the size is computed by EstimateSize.
+func (p *LRUCache) InsertAuto(key string, value interface{}, deleter func(key string, value interface{})) (handle io.Closer) {
+	return p.Insert(key, value, EstimateSize(value), deleter)
+}
+
+// SetAuto same as Set, but the size is computed by EstimateSize.
+func (p *ShardedLRUCache) SetAuto(key string, value interface{}, deleter ...func(key string, value interface{})) {
+	p.Set(key, value, EstimateSize(value), deleter...)
+}
+
+// InsertAuto same as Insert, but the size is computed by EstimateSize.
+func (p *ShardedLRUCache) InsertAuto(key string, value interface{}, deleter func(key string, value interface{})) (handle io.Closer) {
+	return p.Insert(key, value, EstimateSize(value), deleter)
+}
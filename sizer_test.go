@@ -0,0 +1,75 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"testing"
+	"unsafe"
+)
+
+type tSizerValue struct{}
+
+func (tSizerValue) Size() int { return 42 }
+
+type tNode struct {
+	Name string
+	Next *tNode
+}
+
+func TestEstimateSize(t *testing.T) {
+	const (
+		ptrSize    = int(unsafe.Sizeof(uintptr(0)))
+		stringSize = int(unsafe.Sizeof(""))
+		sliceSize  = int(unsafe.Sizeof([]int(nil)))
+	)
+
+	tAssertEQ(t, 42, EstimateSize(tSizerValue{}))
+	tAssertEQ(t, 1, EstimateSize(nil))
+	tAssertEQ(t, 8, EstimateSize(int64(1)))
+	tAssertEQ(t, stringSize+5, EstimateSize("hello"))
+	tAssertEQ(t, sliceSize+10, EstimateSize(make([]byte, 5, 10)))
+	tAssertEQ(t, sliceSize+2*stringSize+3, EstimateSize([]string{"a", "bc"}))
+
+	// map entries are counted
+	m1 := EstimateSize(map[string]int{})
+	m2 := EstimateSize(map[string]int{"abc": 1})
+	tAssertEQ(t, stringSize+8+3, m2-m1)
+
+	// struct and pointer
+	node := &tNode{Name: "abc"}
+	nodeSize := int(unsafe.Sizeof(tNode{}))
+	tAssertEQ(t, ptrSize+nodeSize+3, EstimateSize(node))
+
+	// cycle is counted once
+	node.Next = node
+	tAssertEQ(t, ptrSize+nodeSize+3, EstimateSize(node))
+
+	// shared memory is counted once
+	s := "shared"
+	tAssertEQ(t, sliceSize+2*stringSize+len(s), EstimateSize([]string{s, s}))
+	p := &tNode{Name: s}
+	tAssertEQ(t, sliceSize+2*ptrSize+nodeSize+len(s), EstimateSize([]*tNode{p, p}))
+
+	// the elements without indirect memory are not examined one by one
+	type point struct{ X, Y float64 }
+	tAssertEQ(t, sliceSize+64<<20, EstimateSize(make([]byte, 64<<20)))
+	tAssertEQ(t, sliceSize+3*16, EstimateSize(make([]point, 3)))
+	tAssertEQ(t, 4*8, EstimateSize([4]int64{}))
+	m3 := EstimateSize(map[int]point{1: {}, 2: {}})
+	tAssertEQ(t, 8+16, m3-EstimateSize(map[int]point{1: {}}))
+}
+
+func TestLRUCache_SetAuto(t *testing.T) {
+	c := NewLRUCache(100)
+	defer c.Close()
+
+	c.SetAuto("a", tSizerValue{})
+	tAssertEQ(t, int64(42), c.Size())
+
+	h := c.InsertAuto("b", tSizerValue{}, nil)
+	tAssertEQ(t, 42, h.(*LRUHandle).Size())
+	h.Close()
+	tAssertEQ(t, int64(84), c.Size())
+}
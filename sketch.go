@@ -0,0 +1,162 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+const (
+	sketchDepth    = 4  // number of the rows of countMinSketch
+	sketchMinWidth = 16 // min number of the counters of a row
+	sketchMaxCount = 15 // max value of the 4-bit counters
+)
+
+// countMinSketch estimates the access frequency of the keys with 4-bit
+// counters, a key is counted by one counter of each row, and the min
+// of the counters is the estimated frequency.
+//
+// A doorkeeper bloom filter is placed in front of the counters, so the
+// keys seen only once do not pollute the counters.  After the number
+// of the accesses reaches 10 times of the width, all the counters are
+// halved and the doorkeeper is cleared, so the old popular keys fade
+// out.
+type countMinSketch struct {
+	rows       [sketchDepth][]uint64 // 16 counters per word
+	mask       uint64                // width - 1
+	doorkeeper []uint64              // bitset of the bloom filter
+	additions  int
+	sampleSize int
+}
+
+func newCountMinSketch(width int) *countMinSketch {
+	s := &countMinSketch{}
+	s.reset(width)
+	return s
+}
+
+// reset clears the sketch, width is rounded up to a power of 2.
+func (s *countMinSketch) reset(width int) {
+	n := sketchMinWidth
+	for n < width {
+		n <<= 1
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint64, n/16)
+	}
+	s.mask = uint64(n - 1)
+	s.doorkeeper = make([]uint64, n/4) // 16 bits per counter
+	s.additions = 0
+	s.sampleSize = 10 * n
+}
+
+// width returns the number of the counters of a row.
+func (s *countMinSketch) width() int {
+	return int(s.mask + 1)
+}
+
+// restore sets the estimated frequency of key to at least freq, it
+// is used to move the frequencies of the known keys to a new sketch.
+func (s *countMinSketch) restore(key string, freq int) {
+	if freq <= 0 {
+		return
+	}
+	h1, h2 := sketchHash(key)
+	s.admit(h1, h2)
+
+	if freq--; freq > sketchMaxCount {
+		freq = sketchMaxCount
+	}
+	for i := range s.rows {
+		idx := (h1 + uint64(i)*h2) & s.mask
+		word, shift := &s.rows[i][idx/16], (idx%16)*4
+		if n := int((*word >> shift) & sketchMaxCount); n < freq {
+			*word += uint64(freq-n) << shift
+		}
+	}
+}
+
+// increment records an access of key.
+func (s *countMinSketch) increment(key string) {
+	h1, h2 := sketchHash(key)
+	if s.admit(h1, h2) {
+		for i := range s.rows {
+			idx := (h1 + uint64(i)*h2) & s.mask
+			word, shift := &s.rows[i][idx/16], (idx%16)*4
+			if (*word>>shift)&sketchMaxCount < sketchMaxCount {
+				*word += 1 << shift
+			}
+		}
+	}
+	if s.additions++; s.additions >= s.sampleSize {
+		s.age()
+	}
+}
+
+// estimate returns the estimated access frequency of key.
+func (s *countMinSketch) estimate(key string) int {
+	h1, h2 := sketchHash(key)
+
+	freq := sketchMaxCount
+	for i := range s.rows {
+		idx := (h1 + uint64(i)*h2) & s.mask
+		if n := int((s.rows[i][idx/16] >> ((idx % 16) * 4)) & sketchMaxCount); n < freq {
+			freq = n
+		}
+	}
+	if s.seen(h1, h2) {
+		freq++
+	}
+	return freq
+}
+
+// admit adds the key to the doorkeeper, it reports whether the key has
+// been in the doorkeeper.
+func (s *countMinSketch) admit(h1, h2 uint64) bool {
+	if s.seen(h1, h2) {
+		return true
+	}
+	bits := uint64(len(s.doorkeeper) * 64)
+	for i := uint64(0); i < 2; i++ {
+		bit := (h2 + i*h1) % bits
+		s.doorkeeper[bit/64] |= 1 << (bit % 64)
+	}
+	return false
+}
+
+func (s *countMinSketch) seen(h1, h2 uint64) bool {
+	bits := uint64(len(s.doorkeeper) * 64)
+	for i := uint64(0); i < 2; i++ {
+		bit := (h2 + i*h1) % bits
+		if s.doorkeeper[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// age halves all the counters and clears the doorkeeper.
+func (s *countMinSketch) age() {
+	for i := range s.rows {
+		for j, word := range s.rows[i] {
+			s.rows[i][j] = (word >> 1) & 0x7777777777777777
+		}
+	}
+	for i := range s.doorkeeper {
+		s.doorkeeper[i] = 0
+	}
+	s.additions /= 2
+}
+
+// sketchHash returns two hashes of key for the double hashing, it is
+// the 64-bit FNV-1a hash and its mixed version.
+func sketchHash(key string) (h1, h2 uint64) {
+	h1 = 14695981039346656037
+	for i := 0; i < len(key); i++ {
+		h1 ^= uint64(key[i])
+		h1 *= 1099511628211
+	}
+	h2 = h1
+	h2 ^= h2 >> 33
+	h2 *= 0xff51afd7ed558ccd
+	h2 ^= h2 >> 33
+	return h1, h2 | 1
+}
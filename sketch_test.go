@@ -0,0 +1,56 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"testing"
+)
+
+func TestCountMinSketch(t *testing.T) {
+	s := newCountMinSketch(100)
+	tAssertEQ(t, 128, s.width())
+	tAssertEQ(t, 0, s.estimate("a"))
+
+	// the first access is kept by the doorkeeper
+	s.increment("a")
+	tAssertEQ(t, 1, s.estimate("a"))
+	tAssertEQ(t, 1, s.additions)
+
+	for i := 0; i < 5; i++ {
+		s.increment("a")
+	}
+	tAssertEQ(t, 6, s.estimate("a"))
+
+	// the 4-bit counters saturate
+	for i := 0; i < 100; i++ {
+		s.increment("b")
+	}
+	tAssertEQ(t, sketchMaxCount+1, s.estimate("b"))
+
+	s2 := newCountMinSketch(200)
+	tAssertEQ(t, 256, s2.width())
+	s2.restore("a", s.estimate("a"))
+	s2.restore("b", s.estimate("b"))
+	tAssertEQ(t, 6, s2.estimate("a"))
+	tAssertEQ(t, sketchMaxCount+1, s2.estimate("b"))
+	tAssertEQ(t, 0, s2.estimate("c"))
+}
+
+func TestCountMinSketch_age(t *testing.T) {
+	s := newCountMinSketch(16)
+	for i := 0; i < 9; i++ {
+		s.increment("a")
+	}
+	tAssertEQ(t, 9, s.estimate("a"))
+
+	// halved after sampleSize accesses
+	for s.additions < s.sampleSize-1 {
+		s.increment("b")
+	}
+	tAssertEQ(t, 9, s.estimate("a"))
+	s.increment("b")
+	tAssertEQ(t, 4, s.estimate("a"))
+	tAssertEQ(t, s.sampleSize/2, s.additions)
+}
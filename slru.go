@@ -0,0 +1,134 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+// assert match interface
+var _ Cache = (*SLRUCache)(nil)
+
+// DefaultProtectedRatio is the protected ratio used by NewSLRUCache
+// when protectedRatio is not in (0, 1).
+const DefaultProtectedRatio = 0.8
+
+// SLRUCache is a segmented LRU cache implementation.
+//
+// The new entries are inserted into the probation segment, and an
+// entry is promoted to the protected segment when it is accessed
+// again.  If the protected segment exceeds its ratio of the capacity,
+// the least recently used entries of it are demoted to the probation
+// segment.  The victims are chosen from the probation segment first,
+// so a single scan (such as a compaction) does not flush the hot
+// entries out of the cache.
+type SLRUCache struct {
+	*policyCache
+}
+
+// NewSLRUCache creates a new empty SLRU cache with the given capacity,
+// protectedRatio is the max ratio of the protected segment to the
+// capacity.  The WithTTL and WithIdleTimeout options are not supported.
+func NewSLRUCache(capacity int64, protectedRatio float64, opts ...Option) *SLRUCache {
+	if protectedRatio <= 0 || protectedRatio >= 1 {
+		protectedRatio = DefaultProtectedRatio
+	}
+
+	policy := &slruPolicy{
+		probation: newLRUSegment(),
+		protected: newLRUSegment(),
+		ratio:     protectedRatio,
+	}
+	p := newPolicyCache(capacity, policy, opts...)
+	policy.capacity = &p.capacity
+	return &SLRUCache{p}
+}
+
+// SetProtectedRatio will set the max ratio of the protected segment to
+// the capacity, it must be in (0, 1).  The protected segment is shrank
+// if it exceeds the new ratio.
+func (p *SLRUCache) SetProtectedRatio(protectedRatio float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	assert(protectedRatio > 0 && protectedRatio < 1)
+	policy := p.policy.(*slruPolicy)
+	policy.ratio = protectedRatio
+	demote(policy.probation, policy.protected, policy.protectedMax())
+}
+
+// ProtectedRatio returns the max ratio of the protected segment to the
+// capacity.
+func (p *SLRUCache) ProtectedRatio() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.policy.(*slruPolicy).ratio
+}
+
+// ProtectedSize returns the size of the protected segment.
+func (p *SLRUCache) ProtectedSize() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.policy.(*slruPolicy).protected.size
+}
+
+type slruPolicy struct {
+	capacity  *int64
+	ratio     float64
+	probation *lruSegment
+	protected *lruSegment
+}
+
+func (p *slruPolicy) protectedMax() int64 {
+	return int64(float64(*p.capacity) * p.ratio)
+}
+
+func (p *slruPolicy) add(h *LRUHandle) {
+	p.probation.pushFront(h)
+}
+
+func (p *slruPolicy) hit(h *LRUHandle) {
+	if segment := segmentOf(h); segment == p.probation {
+		promote(h, p.probation, p.protected, p.protectedMax())
+	} else {
+		segment.moveToFront(h)
+	}
+}
+
+func (p *slruPolicy) remove(h *LRUHandle) {
+	segmentOf(h).remove(h)
+}
+
+func (p *slruPolicy) replace(old, h *LRUHandle) {
+	segmentOf(old).replace(old, h)
+	demote(p.probation, p.protected, p.protectedMax())
+}
+
+func (p *slruPolicy) resized(h *LRUHandle) {
+	segmentOf(h).resized(h)
+	demote(p.probation, p.protected, p.protectedMax())
+}
+
+func (p *slruPolicy) front() *LRUHandle {
+	if h := p.protected.front(); h != nil {
+		return h
+	}
+	return p.probation.front()
+}
+
+func (p *slruPolicy) oldest() *LRUHandle {
+	if h := p.probation.back(); h != nil {
+		return h
+	}
+	return p.protected.back()
+}
+
+// appendKeys appends the keys of the protected segment and then the
+// probation segment.
+func (p *slruPolicy) appendKeys(keys []string) []string {
+	keys = p.protected.appendKeys(keys)
+	return p.probation.appendKeys(keys)
+}
+
+func (p *slruPolicy) clear() {
+	p.probation.clear()
+	p.protected.clear()
+}
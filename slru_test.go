@@ -0,0 +1,76 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"testing"
+)
+
+func TestSLRUCache(t *testing.T) {
+	var deleted []string
+	c := NewSLRUCache(4, 0.5, WithDeleter(func(key string, value interface{}) {
+		deleted = append(deleted, key)
+	}))
+	defer c.Close()
+
+	tAssertEQ(t, 0.5, c.ProtectedRatio())
+
+	c.Set("a", 1, 1)
+	c.Set("b", 2, 1)
+	c.Set("c", 3, 1)
+	c.Get("a")
+	tAssertEQ(t, []string{"a", "c", "b"}, c.Keys())
+	tAssertEQ(t, int64(1), c.ProtectedSize())
+
+	// the probation segment is evicted first
+	c.Set("d", 4, 1)
+	c.Set("e", 5, 1)
+	tAssertEQ(t, []string{"b"}, deleted)
+	tAssertEQ(t, []string{"a", "e", "d", "c"}, c.Keys())
+
+	// the protected segment is limited by the ratio
+	c.Get("c")
+	c.Get("d")
+	tAssertEQ(t, []string{"d", "c", "a", "e"}, c.Keys())
+	tAssertEQ(t, int64(2), c.ProtectedSize())
+	tAssertEQ(t, "d", c.FrontKey())
+	tAssertEQ(t, "e", c.BackKey())
+
+	c.SetProtectedRatio(0.25)
+	tAssertEQ(t, []string{"d", "c", "a", "e"}, c.Keys())
+	tAssertEQ(t, int64(1), c.ProtectedSize())
+	tAssertPanic(t, func() { c.SetProtectedRatio(1) })
+
+	// the updated entry stays in the protected segment
+	c.Set("d", 40, 1)
+	tAssertEQ(t, []string{"d", "c", "a", "e"}, c.Keys())
+	tAssertEQ(t, int64(1), c.ProtectedSize())
+	tAssertEQ(t, 40, c.Value("d"))
+
+	c2 := NewSLRUCache(10, 0)
+	defer c2.Close()
+	tAssertEQ(t, DefaultProtectedRatio, c2.ProtectedRatio())
+}
+
+func TestSLRUCache_weighted(t *testing.T) {
+	c := NewSLRUCache(10, 0.5)
+	defer c.Close()
+
+	c.Set("a", 1, 3)
+	c.Set("b", 2, 3)
+	c.Get("a")
+	c.Get("b")
+	tAssertEQ(t, int64(3), c.ProtectedSize())
+	tAssertEQ(t, []string{"b", "a"}, c.Keys())
+
+	tAssertNil(t, c.Resize("b", 5))
+	tAssertEQ(t, int64(5), c.ProtectedSize())
+	tAssertNil(t, c.Resize("b", 6))
+	tAssertEQ(t, int64(6), c.ProtectedSize())
+	tAssertEQ(t, int64(9), c.Size())
+
+	c.Set("c", 3, 2)
+	tAssertEQ(t, []string{"b", "c"}, c.Keys())
+}
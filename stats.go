@@ -0,0 +1,261 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the stats of the cache.
+type Stats struct {
+	Length       int64
+	Size         int64
+	Capacity     int64
+	MaxEntries   int64     // 0 means no limit
+	NewestAccess time.Time // IsZero() if the cache is empty
+	OldestAccess time.Time // IsZero() if the cache is empty
+	CacheStats
+
+	// stats of each shard, only for ShardedLRUCache
+	Shards []Stats
+}
+
+// MarshalJSON implements the json.Marshaler interface, the timestamps
+// are in RFC 3339 format, or null if the cache is empty.
+func (s Stats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Length       int64
+		Size         int64
+		Capacity     int64
+		MaxEntries   int64
+		NewestAccess *string
+		OldestAccess *string
+		Hits         uint64
+		Misses       uint64
+		HitRatio     float64
+		Inserts      uint64
+		Replacements uint64
+		Evictions    uint64
+		Erases       uint64
+		Deletes      uint64
+		LoadErrors   uint64
+		LoadWaits    uint64
+		Shards       []Stats `json:",omitempty"`
+	}{
+		Length:       s.Length,
+		Size:         s.Size,
+		Capacity:     s.Capacity,
+		MaxEntries:   s.MaxEntries,
+		NewestAccess: formatTime(s.NewestAccess),
+		OldestAccess: formatTime(s.OldestAccess),
+		Hits:         s.Hits,
+		Misses:       s.Misses,
+		HitRatio:     s.HitRatio(),
+		Inserts:      s.Inserts,
+		Replacements: s.Replacements,
+		Evictions:    s.Evictions,
+		Erases:       s.Erases,
+		Deletes:      s.Deletes,
+		LoadErrors:   s.LoadErrors,
+		LoadWaits:    s.LoadWaits,
+		Shards:       s.Shards,
+	})
+}
+
+func formatTime(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	s := t.Format(time.RFC3339Nano)
+	return &s
+}
+
+// CacheStats is the counters of the cache.
+type CacheStats struct {
+	Hits         uint64 // Lookup found the entry
+	Misses       uint64 // Lookup not found the entry
+	Inserts      uint64 // entries inserted
+	Replacements uint64 // entries replaced by the new entries of the same key
+	Evictions    uint64 // entries evicted for the capacity
+	Erases       uint64 // entries erased by Erase
+	Deletes      uint64 // deleter invocations
+	LoadErrors   uint64 // GetFrom loader returned error
+	LoadWaits    uint64 // GetFrom waited for the in-flight load of others
+}
+
+// HitRatio returns the ratio of hits in all lookups, or 0 if there is
+// no lookup.
+func (s CacheStats) HitRatio() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+func (s *CacheStats) add(v CacheStats) {
+	s.Hits += v.Hits
+	s.Misses += v.Misses
+	s.Inserts += v.Inserts
+	s.Replacements += v.Replacements
+	s.Evictions += v.Evictions
+	s.Erases += v.Erases
+	s.Deletes += v.Deletes
+	s.LoadErrors += v.LoadErrors
+	s.LoadWaits += v.LoadWaits
+}
+
+// cacheCounters collects the CacheStats, nil means disabled.
+type cacheCounters struct {
+	hits         uint64
+	misses       uint64
+	inserts      uint64
+	replacements uint64
+	evictions    uint64
+	erases       uint64
+	deletes      uint64
+	loadErrors   uint64
+	loadWaits    uint64
+}
+
+func newCacheCounters(enabled bool) *cacheCounters {
+	if !enabled {
+		return nil
+	}
+	return new(cacheCounters)
+}
+
+func (c *cacheCounters) hit() {
+	if c != nil {
+		atomic.AddUint64(&c.hits, 1)
+	}
+}
+
+func (c *cacheCounters) miss() {
+	if c != nil {
+		atomic.AddUint64(&c.misses, 1)
+	}
+}
+
+func (c *cacheCounters) insert() {
+	if c != nil {
+		atomic.AddUint64(&c.inserts, 1)
+	}
+}
+
+func (c *cacheCounters) replace() {
+	if c != nil {
+		atomic.AddUint64(&c.replacements, 1)
+	}
+}
+
+func (c *cacheCounters) evict() {
+	if c != nil {
+		atomic.AddUint64(&c.evictions, 1)
+	}
+}
+
+func (c *cacheCounters) erase() {
+	if c != nil {
+		atomic.AddUint64(&c.erases, 1)
+	}
+}
+
+func (c *cacheCounters) delete() {
+	if c != nil {
+		atomic.AddUint64(&c.deletes, 1)
+	}
+}
+
+func (c *cacheCounters) loadError() {
+	if c != nil {
+		atomic.AddUint64(&c.loadErrors, 1)
+	}
+}
+
+func (c *cacheCounters) loadWait() {
+	if c != nil {
+		atomic.AddUint64(&c.loadWaits, 1)
+	}
+}
+
+func (c *cacheCounters) snapshot() (s CacheStats) {
+	if c != nil {
+		s.Hits = atomic.LoadUint64(&c.hits)
+		s.Misses = atomic.LoadUint64(&c.misses)
+		s.Inserts = atomic.LoadUint64(&c.inserts)
+		s.Replacements = atomic.LoadUint64(&c.replacements)
+		s.Evictions = atomic.LoadUint64(&c.evictions)
+		s.Erases = atomic.LoadUint64(&c.erases)
+		s.Deletes = atomic.LoadUint64(&c.deletes)
+		s.LoadErrors = atomic.LoadUint64(&c.loadErrors)
+		s.LoadWaits = atomic.LoadUint64(&c.loadWaits)
+	}
+	return
+}
+
+func (c *cacheCounters) reset() {
+	if c != nil {
+		atomic.StoreUint64(&c.hits, 0)
+		atomic.StoreUint64(&c.misses, 0)
+		atomic.StoreUint64(&c.inserts, 0)
+		atomic.StoreUint64(&c.replacements, 0)
+		atomic.StoreUint64(&c.evictions, 0)
+		atomic.StoreUint64(&c.erases, 0)
+		atomic.StoreUint64(&c.deletes, 0)
+		atomic.StoreUint64(&c.loadErrors, 0)
+		atomic.StoreUint64(&c.loadWaits, 0)
+	}
+}
+
+// CacheStats returns the counters of the cache, all the counters are
+// zero if the stats collection is disabled.
+func (p *LRUCache) CacheStats() CacheStats {
+	return p.stats.snapshot()
+}
+
+// ResetStats resets all the counters of the cache to zero.
+func (p *LRUCache) ResetStats() {
+	p.stats.reset()
+}
+
+// StatsSnapshot returns all the stats of the cache.  The closed cache
+// has no entries, so only the capacity and counters are reported.
+func (p *LRUCache) StatsSnapshot() (s Stats) {
+	p.mu.Lock()
+	s.Size = p.size
+	s.Capacity = p.capacity
+	s.MaxEntries = p.max_entries
+	if p.list != nil {
+		s.Length = int64(p.list.Len())
+		if frontElem := p.list.Front(); frontElem != nil {
+			s.NewestAccess = frontElem.Value.(*LRUHandle).TimeAccessed()
+		}
+		if lastElem := p.list.Back(); lastElem != nil {
+			s.OldestAccess = lastElem.Value.(*LRUHandle).TimeAccessed()
+		}
+	}
+	p.mu.Unlock()
+
+	s.CacheStats = p.stats.snapshot()
+	return
+}
+
+// StatsJSON returns stats as a JSON object in a string.
+func (p *LRUCache) StatsJSON() string {
+	if p == nil {
+		return "{}"
+	}
+	return marshalStats(p.StatsSnapshot())
+}
+
+func marshalStats(s Stats) string {
+	data, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
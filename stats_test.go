@@ -0,0 +1,129 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLRUCache_CacheStats(t *testing.T) {
+	c := tNewTCache(3)
+	defer c.Close()
+
+	c.Insert(1, 101)
+	c.Insert(2, 201)
+	c.Insert(2, 202) // replace
+	c.Insert(3, 301)
+	c.Insert(4, 401) // evict 1
+	c.Erase(3)
+
+	tAssertEQ(t, 202, c.Lookup(2))
+	tAssertEQ(t, -1, c.Lookup(1))
+	tAssertEQ(t, -1, c.Lookup(3))
+
+	errLoad := errors.New("load failed")
+	c.GetFrom("5", func(key string) (interface{}, int, error) {
+		return nil, 0, errLoad
+	})
+
+	stats := c.CacheStats()
+	tAssertEQ(t, CacheStats{
+		Hits:         1,
+		Misses:       3,
+		Inserts:      5,
+		Replacements: 1,
+		Evictions:    1,
+		Erases:       1,
+		Deletes:      3,
+		LoadErrors:   1,
+	}, stats)
+	tAssertEQ(t, 0.25, stats.HitRatio())
+
+	json := c.StatsJSON()
+	tAssertTrue(t, strings.Contains(json, `"Hits": 1,`), json)
+	tAssertTrue(t, strings.Contains(json, `"LoadErrors": 1,`), json)
+
+	c.ResetStats()
+	tAssertEQ(t, CacheStats{}, c.CacheStats())
+	tAssertEQ(t, 0.0, c.CacheStats().HitRatio())
+}
+
+func TestLRUCache_StatsJSON(t *testing.T) {
+	t0 := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := NewFakeClock(t0)
+	c := NewLRUCache(10, WithClock(clock))
+	defer c.Close()
+
+	var v map[string]interface{}
+	tAssertNil(t, json.Unmarshal([]byte(c.StatsJSON()), &v))
+	tAssertNil(t, v["OldestAccess"])
+	tAssertNil(t, v["NewestAccess"])
+	tAssertFalse(t, tMapHasKey(v, "Shards"))
+
+	c.Set("a", 1, 1)
+	clock.Advance(time.Second)
+	c.Set("b", 2, 3)
+	c.Get("a")
+
+	v = nil
+	tAssertNil(t, json.Unmarshal([]byte(c.StatsJSON()), &v))
+	tAssertEQ(t, 2.0, v["Length"])
+	tAssertEQ(t, 4.0, v["Size"])
+	tAssertEQ(t, 10.0, v["Capacity"])
+	tAssertEQ(t, "2018-01-02T03:04:06Z", v["OldestAccess"])
+	tAssertEQ(t, "2018-01-02T03:04:06Z", v["NewestAccess"])
+	tAssertEQ(t, 1.0, v["Hits"])
+	tAssertEQ(t, 1.0, v["HitRatio"])
+	tAssertEQ(t, 2.0, v["Inserts"])
+
+	// the closed cache has no entries
+	c.Close()
+	v = nil
+	tAssertNil(t, json.Unmarshal([]byte(c.StatsJSON()), &v))
+	tAssertEQ(t, 0.0, v["Length"])
+	tAssertEQ(t, 0.0, v["Size"])
+	tAssertNil(t, v["OldestAccess"])
+	tAssertNil(t, v["NewestAccess"])
+	tAssertEQ(t, 2.0, v["Inserts"])
+
+	var nilCache *LRUCache
+	tAssertEQ(t, "{}", nilCache.StatsJSON())
+}
+
+func TestShardedLRUCache_StatsJSON(t *testing.T) {
+	c := NewShardedLRUCache(100, 4)
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		c.Set(string(rune('a'+i)), i, 1)
+	}
+
+	var v struct {
+		Length int64
+		Shards []struct {
+			Length       int64
+			OldestAccess *time.Time
+		}
+	}
+	tAssertNil(t, json.Unmarshal([]byte(c.StatsJSON()), &v))
+	tAssertEQ(t, int64(10), v.Length)
+	tAssertEQ(t, 4, len(v.Shards))
+
+	var n int64
+	for _, s := range v.Shards {
+		n += s.Length
+		tAssertEQ(t, s.Length == 0, s.OldestAccess == nil)
+	}
+	tAssertEQ(t, int64(10), n)
+}
+
+func tMapHasKey(m map[string]interface{}, key string) bool {
+	_, ok := m[key]
+	return ok
+}
@@ -0,0 +1,195 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+// assert match interface
+var _ Cache = (*TinyLFUCache)(nil)
+
+// TinyLFUCache is a W-TinyLFU cache implementation.
+//
+// The new entries are inserted into a small window LRU (1% of the
+// capacity), the entries evicted from the window are candidates of
+// the main segmented LRU, which has a probation segment and a
+// protected segment (80% of the main).  If the cache is full, a
+// candidate is admitted only if its access frequency is higher than
+// the victim of the probation segment, otherwise the candidate itself
+// is evicted.
+//
+// The access frequency is estimated by a 4-bit count-min sketch with a
+// doorkeeper bloom filter, which is aged periodically.  Both the hits
+// and the misses are counted.
+//
+// See https://arxiv.org/abs/1512.00727
+type TinyLFUCache struct {
+	*policyCache
+}
+
+// NewTinyLFUCache creates a new empty W-TinyLFU cache with the given
+// capacity.  The WithTTL and WithIdleTimeout options are not supported.
+func NewTinyLFUCache(capacity int64, opts ...Option) *TinyLFUCache {
+	policy := newTinyLFUPolicy()
+	p := newPolicyCache(capacity, policy, opts...)
+	policy.capacity = &p.capacity
+	return &TinyLFUCache{p}
+}
+
+// Freq returns the estimated access frequency of key, the key may be
+// not in the cache.
+func (p *TinyLFUCache) Freq(key string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.policy.(*tinyLFUPolicy).sketch.estimate(key)
+}
+
+type tinyLFUPolicy struct {
+	capacity *int64
+	sketch   *countMinSketch
+
+	window    *lruSegment
+	probation *lruSegment
+	protected *lruSegment
+
+	// size of the new entry, set by prepare
+	pending int64
+}
+
+func newTinyLFUPolicy() *tinyLFUPolicy {
+	return &tinyLFUPolicy{
+		sketch:    newCountMinSketch(sketchMinWidth),
+		window:    newLRUSegment(),
+		probation: newLRUSegment(),
+		protected: newLRUSegment(),
+	}
+}
+
+// windowMax returns the max size of the window, it is 1% of the
+// capacity.
+func (p *tinyLFUPolicy) windowMax() int64 {
+	if n := *p.capacity / 100; n > 0 {
+		return n
+	}
+	return 1
+}
+
+// protectedMax returns the max size of the protected segment, it is
+// 80% of the main.
+func (p *tinyLFUPolicy) protectedMax() int64 {
+	return (*p.capacity - p.windowMax()) * 80 / 100
+}
+
+func (p *tinyLFUPolicy) prepare(key string, size int64) {
+	p.pending = size
+}
+
+func (p *tinyLFUPolicy) miss(key string) {
+	p.sketch.increment(key)
+}
+
+// add puts the new entry to the window, and moves the entries out of
+// the window to the probation segment.  The candidates have been
+// admitted by back.
+func (p *tinyLFUPolicy) add(h *LRUHandle) {
+	if n := p.window.Len() + p.probation.Len() + p.protected.Len() + 1; n > p.sketch.width() {
+		p.growSketch(n)
+	}
+	p.sketch.increment(h.key)
+	p.pending = 0
+
+	p.window.pushFront(h)
+	for p.window.size > p.windowMax() && p.window.Len() > 1 {
+		candidate := p.window.back()
+		p.window.remove(candidate)
+		p.probation.pushFront(candidate)
+	}
+}
+
+// growSketch replaces the sketch with a wider one, the frequencies of
+// the keys in the cache are kept.
+func (p *tinyLFUPolicy) growSketch(width int) {
+	old := p.sketch
+	p.sketch = newCountMinSketch(width)
+	for _, segment := range []*lruSegment{p.window, p.probation, p.protected} {
+		for e := segment.list.Front(); e != nil; e = e.Next() {
+			key := e.Value.(*LRUHandle).key
+			p.sketch.restore(key, old.estimate(key))
+		}
+	}
+}
+
+// hit moves the entry to the front of its segment, the entry of the
+// probation segment is promoted to the protected segment.
+func (p *tinyLFUPolicy) hit(h *LRUHandle) {
+	p.sketch.increment(h.key)
+
+	switch segment := segmentOf(h); segment {
+	case p.probation:
+		promote(h, p.probation, p.protected, p.protectedMax())
+	default:
+		segment.moveToFront(h)
+	}
+}
+
+func (p *tinyLFUPolicy) remove(h *LRUHandle) {
+	segmentOf(h).remove(h)
+}
+
+func (p *tinyLFUPolicy) replace(old, h *LRUHandle) {
+	segmentOf(old).replace(old, h)
+}
+
+func (p *tinyLFUPolicy) resized(h *LRUHandle) {
+	segmentOf(h).resized(h)
+}
+
+func (p *tinyLFUPolicy) front() *LRUHandle {
+	if h := p.protected.front(); h != nil {
+		return h
+	}
+	if h := p.window.front(); h != nil {
+		return h
+	}
+	return p.probation.front()
+}
+
+// oldest returns the loser of the candidate and the victim.  The
+// candidate is the back of the window if the window will overflow
+// after the pending entry added, the victim is the back of the main.
+func (p *tinyLFUPolicy) oldest() *LRUHandle {
+	var candidate, victim *LRUHandle
+	if p.window.size+p.pending > p.windowMax() {
+		candidate = p.window.back()
+	}
+	if victim = p.probation.back(); victim == nil {
+		victim = p.protected.back()
+	}
+
+	switch {
+	case victim == nil && candidate == nil:
+		return p.window.back()
+	case victim == nil:
+		return candidate
+	case candidate == nil:
+		return victim
+	}
+	if p.sketch.estimate(candidate.key) > p.sketch.estimate(victim.key) {
+		return victim
+	}
+	return candidate
+}
+
+// appendKeys appends the keys of the protected segment, the window
+// and the probation segment.
+func (p *tinyLFUPolicy) appendKeys(keys []string) []string {
+	keys = p.protected.appendKeys(keys)
+	keys = p.window.appendKeys(keys)
+	return p.probation.appendKeys(keys)
+}
+
+func (p *tinyLFUPolicy) clear() {
+	p.window.clear()
+	p.probation.clear()
+	p.protected.clear()
+	p.pending = 0
+}
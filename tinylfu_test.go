@@ -0,0 +1,65 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestTinyLFUCache_admission(t *testing.T) {
+	var evicted []string
+	c := NewTinyLFUCache(10, WithEvictionListener(func(key string, value interface{}, reason EvictReason) {
+		evicted = append(evicted, key)
+	}))
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		c.Set("f"+strconv.Itoa(i), i, 1)
+	}
+	for i := 0; i < 5; i++ {
+		_, ok := c.Get("pop")
+		tAssertFalse(t, ok)
+	}
+	tAssertTrue(t, c.Freq("pop") > c.Freq("f0"))
+
+	// the candidate is not more popular than the victim
+	c.Set("pop", 0, 1)
+	tAssertEQ(t, []string{"f9"}, evicted)
+
+	// the popular candidate is admitted
+	c.Set("new", 0, 1)
+	tAssertEQ(t, []string{"f9", "f0"}, evicted)
+	tAssertTrue(t, c.HasKey("pop"))
+	tAssertTrue(t, c.HasKey("new"))
+	tAssertEQ(t, int64(10), c.Length())
+}
+
+func TestTinyLFUCache_segments(t *testing.T) {
+	c := NewTinyLFUCache(10)
+	defer c.Close()
+
+	tiny := c.policy.(*tinyLFUPolicy)
+	tAssertEQ(t, int64(1), tiny.windowMax())
+	tAssertEQ(t, int64(7), tiny.protectedMax())
+
+	c.Set("a", 1, 1)
+	c.Set("b", 2, 1)
+	tAssertTrue(t, segmentOf(c.table["b"]) == tiny.window)
+	tAssertTrue(t, segmentOf(c.table["a"]) == tiny.probation)
+
+	// promoted on hit
+	c.Get("a")
+	tAssertTrue(t, segmentOf(c.table["a"]) == tiny.protected)
+	tAssertEQ(t, []string{"a", "b"}, c.Keys())
+
+	tAssertNil(t, c.Resize("a", 3))
+	tAssertEQ(t, int64(3), tiny.protected.size)
+	tAssertEQ(t, int64(4), c.Size())
+
+	c.Clear()
+	tAssertEQ(t, int64(0), tiny.protected.size)
+	tAssertEQ(t, 0, len(c.Keys()))
+}
@@ -0,0 +1,184 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+// assert match interface
+var _ Cache = (*TwoQueueCache)(nil)
+
+const (
+	// DefaultRecentRatio is the ratio of the A1in queue to the capacity
+	// used by NewTwoQueueCache when recentRatio is not in (0, 1).
+	DefaultRecentRatio = 0.25
+
+	// DefaultGhostRatio is the ratio of the A1out queue to the capacity
+	// used by NewTwoQueueCache when ghostRatio is not positive.
+	DefaultGhostRatio = 0.5
+)
+
+// TwoQueueCache is a 2Q cache implementation.
+//
+// The new entries are inserted into the A1in FIFO queue, and the hits
+// of the A1in entries do not change the order.  The entries out of
+// A1in are remembered in the A1out queue as ghosts, which hold only
+// the keys and sizes, not the values.  If a key of A1out is inserted
+// again, the entry goes to the Am LRU queue directly.
+//
+// The victims are chosen from A1in if it exceeds its ratio of the
+// capacity, otherwise from Am.  The sizes of all the queues are the
+// total sum of the Size() of the entries.
+//
+// See http://www.vldb.org/conf/1994/P439.PDF
+type TwoQueueCache struct {
+	*policyCache
+}
+
+// NewTwoQueueCache creates a new empty 2Q cache with the given capacity,
+// recentRatio is the ratio of the A1in queue to the capacity, and
+// ghostRatio is the ratio of the A1out queue to the capacity.  The
+// WithTTL and WithIdleTimeout options are not supported.
+func NewTwoQueueCache(capacity int64, recentRatio, ghostRatio float64, opts ...Option) *TwoQueueCache {
+	if recentRatio <= 0 || recentRatio >= 1 {
+		recentRatio = DefaultRecentRatio
+	}
+	if ghostRatio <= 0 {
+		ghostRatio = DefaultGhostRatio
+	}
+
+	policy := &twoQueuePolicy{
+		recent_ratio: recentRatio,
+		ghost_ratio:  ghostRatio,
+		a1in:         newLRUSegment(),
+		a1out:        newGhostQueue(),
+		am:           newLRUSegment(),
+	}
+	p := newPolicyCache(capacity, policy, opts...)
+	policy.capacity = &p.capacity
+	return &TwoQueueCache{p}
+}
+
+// SetRatios will set the ratios of the A1in and A1out queues, see
+// NewTwoQueueCache.
+func (p *TwoQueueCache) SetRatios(recentRatio, ghostRatio float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	assert(recentRatio > 0 && recentRatio < 1 && ghostRatio > 0)
+	policy := p.policy.(*twoQueuePolicy)
+	policy.recent_ratio = recentRatio
+	policy.ghost_ratio = ghostRatio
+	policy.trimGhosts()
+}
+
+// Ratios returns the ratios of the A1in and A1out queues.
+func (p *TwoQueueCache) Ratios() (recentRatio, ghostRatio float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	policy := p.policy.(*twoQueuePolicy)
+	return policy.recent_ratio, policy.ghost_ratio
+}
+
+type twoQueuePolicy struct {
+	capacity     *int64
+	recent_ratio float64
+	ghost_ratio  float64
+
+	a1in  *lruSegment // FIFO, the front is the newest
+	a1out *ghostQueue
+	am    *lruSegment
+
+	// the new entry hits the ghost queue, set by prepare
+	pending bool
+}
+
+func (p *twoQueuePolicy) recentMax() int64 {
+	return int64(float64(*p.capacity) * p.recent_ratio)
+}
+
+func (p *twoQueuePolicy) ghostMax() int64 {
+	return int64(float64(*p.capacity) * p.ghost_ratio)
+}
+
+func (p *twoQueuePolicy) prepare(key string, size int64) {
+	_, p.pending = p.a1out.lookup(key)
+	p.a1out.remove(key)
+}
+
+// add puts the new entry to the front of Am if its key is a ghost,
+// else to the front of A1in.
+func (p *twoQueuePolicy) add(h *LRUHandle) {
+	if p.pending {
+		p.am.pushFront(h)
+	} else {
+		p.a1in.pushFront(h)
+	}
+	p.pending = false
+}
+
+// hit moves the entry of Am to the front, the entry of A1in is kept.
+func (p *twoQueuePolicy) hit(h *LRUHandle) {
+	if segment := segmentOf(h); segment == p.am {
+		segment.moveToFront(h)
+	}
+}
+
+func (p *twoQueuePolicy) remove(h *LRUHandle) {
+	segmentOf(h).remove(h)
+}
+
+// evict removes the entry, and remembers its key in A1out if it is
+// evicted from A1in.
+func (p *twoQueuePolicy) evict(h *LRUHandle) {
+	link := h.link.(*segmentLink)
+	p.remove(h)
+
+	if link.segment == p.a1in {
+		p.a1out.push(h, link.size)
+		p.trimGhosts()
+	}
+}
+
+func (p *twoQueuePolicy) replace(old, h *LRUHandle) {
+	segmentOf(old).replace(old, h)
+}
+
+func (p *twoQueuePolicy) resized(h *LRUHandle) {
+	segmentOf(h).resized(h)
+}
+
+func (p *twoQueuePolicy) front() *LRUHandle {
+	if h := p.am.front(); h != nil {
+		return h
+	}
+	return p.a1in.front()
+}
+
+// oldest returns the back of A1in if A1in exceeds its ratio of the
+// capacity, else the back of Am.
+func (p *twoQueuePolicy) oldest() *LRUHandle {
+	if p.a1in.Len() > 0 && (p.am.Len() == 0 || p.a1in.size > p.recentMax()) {
+		return p.a1in.back()
+	}
+	return p.am.back()
+}
+
+// appendKeys appends the keys of Am and then A1in.
+func (p *twoQueuePolicy) appendKeys(keys []string) []string {
+	keys = p.am.appendKeys(keys)
+	return p.a1in.appendKeys(keys)
+}
+
+func (p *twoQueuePolicy) clear() {
+	p.a1in.clear()
+	p.a1out.clear()
+	p.am.clear()
+	p.pending = false
+}
+
+func (p *twoQueuePolicy) trimGhosts() {
+	for max := p.ghostMax(); p.a1out.size > max && p.a1out.Len() > 0; {
+		p.a1out.removeBack()
+	}
+}
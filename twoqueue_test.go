@@ -0,0 +1,98 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestTwoQueueCache(t *testing.T) {
+	var deleted []string
+	c := NewTwoQueueCache(4, 0.5, 0.5, WithDeleter(func(key string, value interface{}) {
+		deleted = append(deleted, key)
+	}))
+	defer c.Close()
+
+	c.Set("a", 1, 1)
+	c.Set("b", 2, 1)
+	c.Set("c", 3, 1)
+	c.Set("d", 4, 1)
+	c.Set("e", 5, 1)
+	tAssertEQ(t, []string{"a"}, deleted)
+
+	// the hit of A1in does not change the order
+	c.Get("b")
+	tAssertEQ(t, []string{"e", "d", "c", "b"}, c.Keys())
+
+	// the ghost goes to Am
+	c.Set("a", 1, 1)
+	tAssertEQ(t, []string{"a", "e", "d", "c"}, c.Keys())
+	tAssertEQ(t, []string{"a", "b"}, deleted)
+
+	// the ghosts are limited by the ratio, and call no deleters
+	c.Set("f", 6, 1)
+	c.Set("g", 7, 1)
+	tAssertEQ(t, []string{"a", "g", "f", "e"}, c.Keys())
+	tAssertEQ(t, []string{"a", "b", "c", "d"}, deleted)
+	a1out := c.policy.(*twoQueuePolicy).a1out
+	tAssertEQ(t, 2, a1out.Len())
+	tAssertEQ(t, int64(2), a1out.size)
+	_, ok := a1out.lookup("b")
+	tAssertFalse(t, ok)
+
+	// b and c are not ghosts any more
+	c.Set("b", 2, 1)
+	c.Set("c", 3, 1)
+	tAssertEQ(t, []string{"a", "c", "b", "g"}, c.Keys())
+
+	// A1in does not exceed the ratio, evicts Am
+	c.SetRatios(0.75, 0.5)
+	c.Set("x", 0, 1)
+	tAssertEQ(t, []string{"x", "c", "b", "g"}, c.Keys())
+	tAssertEQ(t, "a", deleted[len(deleted)-1])
+	tAssertEQ(t, "x", c.FrontKey())
+	tAssertEQ(t, "g", c.BackKey())
+}
+
+func TestTwoQueueCache_ratios(t *testing.T) {
+	c := NewTwoQueueCache(100, 0, 0)
+	defer c.Close()
+
+	recent, ghost := c.Ratios()
+	tAssertEQ(t, DefaultRecentRatio, recent)
+	tAssertEQ(t, DefaultGhostRatio, ghost)
+
+	for i := 0; i < 200; i++ {
+		c.Set(strconv.Itoa(i), i, 1)
+	}
+	a1out := c.policy.(*twoQueuePolicy).a1out
+	tAssertEQ(t, 50, a1out.Len())
+
+	c.SetRatios(0.5, 0.1)
+	tAssertEQ(t, 10, a1out.Len())
+	tAssertPanic(t, func() { c.SetRatios(0, 0.1) })
+}
+
+func TestTwoQueueCache_weighted(t *testing.T) {
+	c := NewTwoQueueCache(10, 0.5, 1)
+	defer c.Close()
+
+	c.Set("a", 1, 4)
+	c.Set("b", 2, 4)
+	c.Set("c", 3, 4)
+	tAssertEQ(t, []string{"c", "b"}, c.Keys())
+
+	// the ghost holds the size
+	a1out := c.policy.(*twoQueuePolicy).a1out
+	tAssertEQ(t, int64(4), a1out.size)
+
+	c.Set("a", 1, 6)
+	tAssertEQ(t, []string{"a", "c"}, c.Keys())
+	tAssertEQ(t, int64(4), a1out.size)
+	_, ok := a1out.lookup("b")
+	tAssertTrue(t, ok)
+	tAssertEQ(t, int64(10), c.Size())
+}
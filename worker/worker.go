@@ -14,6 +14,7 @@ import (
 )
 
 type Worker struct {
+	clock  cache.Clock
 	tasks  *cache.LRUCache
 	stoped chan bool
 	wg     sync.WaitGroup
@@ -51,12 +52,18 @@ func (p *_WorkerItem) DoTask() {
 	p.task()
 }
 
-func NewWorker(taskCacheSize int) *Worker {
+// NewWorker creates a new worker, the optional clock is used to
+// expire the tasks (default is cache.SystemClock).
+func NewWorker(taskCacheSize int, clock ...cache.Clock) *Worker {
 	assert(taskCacheSize > 0)
 
 	p := &Worker{
-		tasks: cache.NewLRUCache(int64(taskCacheSize)),
+		clock: cache.SystemClock,
 	}
+	if len(clock) > 0 && clock[0] != nil {
+		p.clock = clock[0]
+	}
+	p.tasks = cache.NewLRUCache(int64(taskCacheSize), cache.WithClock(p.clock))
 	p.wg.Add(1)
 	return p
 }
@@ -73,6 +80,17 @@ func (p *Worker) AddTask(key interface{}, task func()) {
 	p.tasks.PushFront(skey, newWorkerItem(task), 1, nil)
 }
 
+// StatsSnapshot returns the stats of the task cache.
+func (p *Worker) StatsSnapshot() cache.Stats {
+	return p.tasks.StatsSnapshot()
+}
+
+// StatsJSON returns the stats of the task cache as a JSON object in a
+// string.
+func (p *Worker) StatsJSON() string {
+	return p.tasks.StatsJSON()
+}
+
 func (p *Worker) Start() {
 	assert(p.stoped == nil)
 
@@ -91,7 +109,7 @@ func (p *Worker) Start() {
 					continue
 				}
 				if h := p.tasks.Front(); h != nil {
-					if h.TimeAccessed().Add(30 * time.Second).After(time.Now()) {
+					if h.TimeAccessed().Add(30 * time.Second).After(p.clock.Now()) {
 						if !h.Value().(*_WorkerItem).IsDone() {
 							h.Value().(*_WorkerItem).DoTask()
 						}